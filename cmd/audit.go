@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Command to find variables that look like secrets but are not sensitive",
+	Long: `Command used to list the variables of a TF workspace that are not sensitive but look like secrets,
+either because their key matches one of the --secret-patterns or because their value looks random.
+The command exits with an error when such a variable is found.
+
+Examples:
+tfc-help audit -w ws-K33Rp -o big-corp
+tfc-help audit --secret-patterns '*SECRET*,*KEY*' -w ws-K33Rp -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		wsName, orgName := getWorkspaceAndOrganization(cmd)
+		workspaceID := helper.GetWorkspaceID(orgName, wsName)
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		found := 0
+		for _, variable := range helper.ListAllVariables(workspaceID) {
+			if variable.Sensitive {
+				continue
+			}
+			if isSecret, reason := helper.LooksLikeSecret(variable.Key, variable.Value); isSecret {
+				if found == 0 {
+					fmt.Fprintln(writer, "KEY\tCATEGORY\tREASON")
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", variable.Key, variable.Category, reason)
+				found++
+			}
+		}
+		writer.Flush()
+
+		if found > 0 {
+			fmt.Printf("\n%d variable(s) in workspace %s look like secrets but are not sensitive\n", found, wsName)
+			os.Exit(1)
+		}
+		fmt.Printf("No variable in workspace %s looks like a secret without being sensitive\n", wsName)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Command to save the variables of a workspace in an encrypted file",
+	Long: `Command used to save every variable of a workspace with all its attributes in a file encrypted with age.
+The API never returns sensitive values, so they are saved empty unless their values are supplied.
+The backup can be restored with the restore command.
+
+Examples:
+tfc-helper backup -w test --out test.age --recipient age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+tfc-helper backup -w test --out test.age --recipients-file recipients.txt --values-file secrets.env.age`,
+	Run: func(cmd *cobra.Command, args []string) {
+		wsName, orgName := getWorkspaceAndOrganization(cmd)
+		out, _ := cmd.Flags().GetString("out")
+		recipients, _ := cmd.Flags().GetStringSlice("recipient")
+		recipientFiles, _ := cmd.Flags().GetStringSlice("recipients-file")
+
+		ageRecipients, err := helper.ParseAgeRecipients(recipients, recipientFiles)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if len(ageRecipients) == 0 {
+			fmt.Println("Please set an age recipient with --recipient or --recipients-file")
+			os.Exit(1)
+		}
+
+		valuesFiles, _ := cmd.Flags().GetStringSlice("values-file")
+		valuePairs, _ := cmd.Flags().GetStringSlice("value")
+		values, err := helper.LoadValues(valuesFiles, valuePairs)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		backup := helper.NewBackup(orgName, wsName, values)
+		if err := helper.WriteBackup(backup, out, ageRecipients); err != nil {
+			fmt.Printf("Failed to write the backup: %s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Saved %d variable(s) of workspace %s to %s\n", len(backup.Variables), wsName, out)
+		if missing := backup.MissingValues(); len(missing) > 0 {
+			fmt.Printf(`These sensitive variables were saved without their values: %s
+Please supply their values with --values-file or --value to include them
+`, strings.Join(missing, ", "))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.Flags().String("out", "", "Specify the file to write the encrypted backup to")
+	_ = backupCmd.MarkFlagRequired("out")
+	backupCmd.Flags().StringSlice("recipient", []string{}, "Specify an age public key to encrypt the backup to")
+	backupCmd.Flags().StringSlice("recipients-file", []string{}, "Specify a file with age public keys to encrypt the backup to, one per line")
+	addSensitiveValueFlags(backupCmd)
+}
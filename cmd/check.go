@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Command to compare the variables of a TF workspace with the variable blocks of a module",
+	Long: `Command used to read the variable blocks of a Terraform module and to compare them with the variables a run
+of the workspace sees, variable sets included. It reports the required variables nothing sets, the terraform
+variables of the workspace no variable block declares, and the variables whose HCL flag or value does not fit
+the declared type. A TF_VAR_ environment variable also sets a variable. The command fails when there is an issue.
+
+Examples:
+tfc-help check --dir ./infra -w ws-K33Rp -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		declared, err := helper.LoadModuleVariables(dir)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if len(declared) == 0 {
+			fmt.Printf("No variable block found in %s\n", dir)
+		}
+
+		wsName, orgName := getWorkspaceAndOrganization(cmd)
+		workspaceID := helper.GetWorkspaceID(orgName, wsName)
+		variables, err := helper.EffectiveVariables(orgName, workspaceID)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		issues := helper.CheckModuleVariables(declared, variables)
+		if len(issues) == 0 {
+			fmt.Printf("The variables of workspace %s match the %d variable block(s) of %s\n", wsName, len(declared), dir)
+			return
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "KEY\tPROBLEM")
+		for _, issue := range issues {
+			fmt.Fprintf(writer, "%s\t%s\n", issue.Key, issue.Problem)
+		}
+		writer.Flush()
+
+		fmt.Printf("\n%d issue(s) found between workspace %s and %s\n", len(issues), wsName, dir)
+		os.Exit(1)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().String("dir", ".", "Directory of the Terraform module")
+}
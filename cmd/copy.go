@@ -3,9 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"sync"
+	"strings"
 	"tfc-helper/helper"
 
+	"github.com/hashicorp/go-tfe"
 	"github.com/spf13/cobra"
 )
 
@@ -24,7 +25,20 @@ OR if the source workspace and source organization have already been set with en
 tfc-helper copy --dst-ws test2
 
 - Copy all variables from workspace test1 to workspace test2 but overwrite the variables that have the same name in test2:
-tfc-help copy --src-ws test1 --dst-ws test2 -r`,
+tfc-help copy --src-ws test1 --dst-ws test2 -r
+
+- Copy all variables from the template workspace to several workspaces, possibly in other organizations,
+or to every workspace matching a selector of the destination organization. The source is listed once
+and a summary is printed for each destination:
+tfc-help copy --src-ws template --dst-ws test2 --dst-ws org2/test3
+tfc-help copy --src-ws template --ws-match 'app-*-prod' --dst-org org2
+
+- The values of sensitive variables cannot be read, so they are only copied when their values are supplied.
+The other sensitive variables are listed at the end:
+tfc-help copy --src-ws test1 --dst-ws test2 --values-file secrets.env.age --value DB_PASSWORD=vault:secret/data/db#password
+
+- Check the variables against a schema before copying them, see the validate command for the schema format:
+tfc-help copy --src-ws test1 --dst-ws test2 --schema schema.yaml`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Try to get value from command line first then try the environment variable
 		srcOrgName, _ := cmd.Flags().GetString("src-org")
@@ -55,66 +69,259 @@ tfc-help copy --src-ws test1 --dst-ws test2 -r`,
 			}
 		}
 
-		dstWsName, _ := cmd.Flags().GetString("dst-ws")
 		shouldReplace, _ := cmd.Flags().GetBool("replace")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		valuePairs, _ := cmd.Flags().GetStringSlice("value")
+
+		// Copies to several workspaces are confirmed
+		if dstWsNames, _ := cmd.Flags().GetStringSlice("dst-ws"); len(dstWsNames) > 1 || workspaceSelector(cmd).IsSet() {
+			requireYesWithStdin(cmd, valuePairs)
+		}
+
+		// The schema is read before any API call
+		schema := loadSchema(cmd)
 
 		srcWorkspaceID := helper.GetWorkspaceID(srcOrgName, srcWsName)
-		dstWorkspaceID := helper.GetWorkspaceID(dstOrgName, dstWsName)
-
-		variablesInDstWs := helper.ListAllVariables(dstWorkspaceID)
-
-		var wg sync.WaitGroup
-		for _, variable := range helper.ListAllVariables(srcWorkspaceID) {
-			// Check if replace flag is set
-			if shouldReplace {
-				wg.Add(1)
-				newVariable := helper.NewVariable{
-					ID:          "",
-					Key:         variable.Key,
-					Value:       variable.Value,
-					Description: variable.Description,
-					Category:    variable.Category,
-					HCL:         variable.HCL,
-					Sensitive:   variable.Sensitive,
+		destinations := getCopyDestinations(cmd, dstOrgName, srcWorkspaceID)
+
+		// The source is listed once for every destination
+		sourceVariables := getSourceVariables(srcWorkspaceID)
+
+		// The API never returns sensitive values, so they have to be supplied
+		valuesFiles, _ := cmd.Flags().GetStringSlice("values-file")
+		values, err := helper.LoadValues(valuesFiles, valuePairs)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		sourceVariables, missingValues := helper.FillSensitiveValues(sourceVariables, values)
+
+		sourceVariables, err = helper.CheckSecrets(sourceVariables)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		workspaces := make([]*tfe.Workspace, 0, len(destinations))
+		for _, destination := range destinations {
+			workspaces = append(workspaces, destination.Workspace)
+		}
+
+		// Every destination is planned and checked before anything is copied
+		plans := make([]workspaceChanges, 0, len(destinations))
+		violations := make([]string, 0)
+		for i, result := range helper.ListVariablesInWorkspaces(workspaces, concurrency) {
+			if result.Err != nil {
+				fmt.Printf("Cannot list the variables of workspace %s: %s\n", destinations[i].Name(), result.Err)
+				os.Exit(1)
+			}
+
+			dstKeys := make([]string, 0, len(result.Variables))
+			for _, variable := range result.Variables {
+				dstKeys = append(dstKeys, variable.Key)
+			}
+
+			changes := make([]helper.Change, 0, len(sourceVariables))
+			variablesToCopy := make([]helper.NewVariable, 0, len(sourceVariables))
+			for _, newVariable := range sourceVariables {
+				change := helper.Change{Action: helper.ActionCreate, Variable: newVariable}
+				for _, variable := range result.Variables {
+					if variable.Key != newVariable.Key {
+						continue
+					}
+					// If a variable already exists -- having the same name, skip unless replace flag is set
+					change.Existing = variable
+					change.Variable.ID = variable.ID
+					change.Action = helper.ActionRecreate
+					if !shouldReplace {
+						change.Action = helper.ActionSkip
+						change.Reason = "already exists"
+					}
 				}
-				// If a variable already exists, delete it and create a new one
-				if helper.CheckIfVariableExistInWs(variablesInDstWs, variable.Key) {
-					// Try to get the variable ID
-					variableToReplace, _ := helper.GetVar(dstWorkspaceID, variable.Key)
-					newVariable.ID = variableToReplace.ID
-					go helper.RecreateVariable(dstWorkspaceID, newVariable, &wg)
-				} else {
-					go helper.CreateVariable(dstWorkspaceID, newVariable, &wg)
+				if change.Action != helper.ActionSkip {
+					variablesToCopy = append(variablesToCopy, newVariable)
 				}
-			} else {
-				// If a variable already exists -- having the same name, skip
-				if helper.CheckIfVariableExistInWs(variablesInDstWs, variable.Key) {
-					continue
-				} else {
-					wg.Add(1)
-					newVariable := helper.NewVariable{
-						ID:          "",
-						Key:         variable.Key,
-						Value:       variable.Value,
-						Description: variable.Description,
-						Category:    variable.Category,
-						HCL:         variable.HCL,
-						Sensitive:   variable.Sensitive,
-					}
-					go helper.CreateVariable(dstWorkspaceID, newVariable, &wg)
+				changes = append(changes, change)
+			}
+
+			if schema != nil {
+				for _, violation := range schema.Validate(variablesToCopy, dstKeys) {
+					violations = append(violations, fmt.Sprintf("%s: %s", destinations[i].Name(), violation))
 				}
 			}
+			plans = append(plans, workspaceChanges{Workspace: result.Workspace, Changes: changes})
+		}
+		exitOnViolations(violations)
+
+		confirmed := true
+		if len(destinations) > 1 || workspaceSelector(cmd).IsSet() {
+			confirmed = confirmWorkspaceChanges(cmd, plans)
+		}
+
+		failed := 0
+		if confirmed {
+			for i, plan := range plans {
+				failed += copyToDestination(srcWsName, destinations[i], plan.Changes)
+			}
+		} else if countChanges(plans) > 0 {
+			// The changes were not confirmed
+			failed++
+		}
+
+		// The missing values are reported even when nothing was copied
+
+		if len(missingValues) > 0 {
+			fmt.Printf(`These sensitive variables were not copied because their values cannot be read: %s
+Please supply their values with --values-file or --value
+`, strings.Join(missingValues, ", "))
+			os.Exit(1)
+		}
+		if failed > 0 {
+			os.Exit(1)
 		}
-		wg.Wait()
 	},
 }
 
+// parseWorkspaceRef splits a workspace given as workspace or organization/workspace.
+// The organization is defaultOrg when it is not given
+func parseWorkspaceRef(ref string, defaultOrg string) (string, string) {
+	if parts := strings.SplitN(ref, "/", 2); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return defaultOrg, ref
+}
+
+// addSensitiveValueFlags adds the flags giving the values of the sensitive variables,
+// which the API never returns
+func addSensitiveValueFlags(command *cobra.Command) {
+	command.Flags().StringSlice("values-file", []string{}, `Specify a variable file with the values of the sensitive variables.
+The file can be in any format of update --var-file, including encrypted files`)
+	command.Flags().StringSlice("value", []string{}, `Specify the value of a sensitive variable as KEY=value.
+The value can be a reference such as KEY=vault:path#field or KEY=env:NAME`)
+}
+
+// getSourceVariables lists the variables of a workspace so they can be written to another one.
+// The values of sensitive variables are empty since the API never returns them
+func getSourceVariables(workspaceID string) []helper.NewVariable {
+	variables := make([]helper.NewVariable, 0)
+	for _, variable := range helper.ListAllVariables(workspaceID) {
+		variables = append(variables, helper.NewVariable{
+			ID:          "",
+			Key:         variable.Key,
+			Value:       variable.Value,
+			Description: variable.Description,
+			Category:    variable.Category,
+			HCL:         variable.HCL,
+			Sensitive:   variable.Sensitive,
+		})
+	}
+	return variables
+}
+
+// copyDestination is a workspace variables are copied to
+type copyDestination struct {
+	Organization string
+	Workspace    *tfe.Workspace
+}
+
+// Name gets the name of the destination as organization/workspace
+func (destination copyDestination) Name() string {
+	return destination.Organization + "/" + destination.Workspace.Name
+}
+
+// getCopyDestinations gets the workspaces given with --dst-ws, as workspace or organization/workspace,
+// and the ones matching the workspace selector flags. The source workspace is never a destination
+func getCopyDestinations(cmd *cobra.Command, dstOrgName string, srcWorkspaceID string) []copyDestination {
+	dstWsNames, _ := cmd.Flags().GetStringSlice("dst-ws")
+
+	destinations, err := resolveCopyDestinations(dstWsNames, dstOrgName, srcWorkspaceID, helper.ListAllWorkspaces, selectWorkspaces(cmd, dstOrgName))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if len(destinations) == 0 {
+		fmt.Println("Please set the destination workspace with the --dst-ws flag or select them with --ws-match, --ws-tag or --ws-file")
+		os.Exit(1)
+	}
+	return destinations
+}
+
+// resolveCopyDestinations finds the workspaces named in refs, as workspace or organization/workspace,
+// then adds the selected workspaces of dstOrgName. listWorkspaces is called once per organization.
+// Every workspace is kept once and the source workspace is left out
+func resolveCopyDestinations(refs []string, dstOrgName string, srcWorkspaceID string, listWorkspaces func(string) []*tfe.Workspace, selected []*tfe.Workspace) ([]copyDestination, error) {
+	destinations := make([]copyDestination, 0)
+	seen := map[string]bool{srcWorkspaceID: true}
+	addDestination := func(orgName string, workspace *tfe.Workspace) {
+		if !seen[workspace.ID] {
+			seen[workspace.ID] = true
+			destinations = append(destinations, copyDestination{Organization: orgName, Workspace: workspace})
+		}
+	}
+
+	// workspacesByOrg keeps the workspaces of each organization so they are listed once
+	workspacesByOrg := make(map[string][]*tfe.Workspace)
+	for _, ref := range refs {
+		orgName, wsName := parseWorkspaceRef(ref, dstOrgName)
+		if _, found := workspacesByOrg[orgName]; !found {
+			workspacesByOrg[orgName] = listWorkspaces(orgName)
+		}
+
+		var destination *tfe.Workspace
+		for _, workspace := range workspacesByOrg[orgName] {
+			if workspace.Name == wsName {
+				destination = workspace
+			}
+		}
+		if destination == nil {
+			return nil, fmt.Errorf("workspace %s not found in organization %s", wsName, orgName)
+		}
+		addDestination(orgName, destination)
+	}
+
+	for _, workspace := range selected {
+		addDestination(dstOrgName, workspace)
+	}
+	return destinations, nil
+}
+
+// copyToDestination applies the changes to a destination and prints its summary.
+// It returns the number of variables that failed to be copied
+func copyToDestination(srcWsName string, destination copyDestination, changes []helper.Change) int {
+	copied, skipped, failed := 0, 0, 0
+	for _, change := range changes {
+		if change.Action == helper.ActionSkip {
+			skipped++
+			continue
+		}
+		if err := helper.ApplyChange(destination.Workspace.ID, change); err != nil {
+			fmt.Printf("Failed to copy %s to workspace %s: %s\n", change.Variable.Key, destination.Name(), err)
+			failed++
+			continue
+		}
+		copied++
+	}
+
+	fmt.Printf("Copied %d variable(s) from workspace %s to workspace %s", copied, srcWsName, destination.Name())
+	if skipped > 0 {
+		fmt.Printf(", skipped %d existing variable(s)", skipped)
+	}
+	if failed > 0 {
+		fmt.Printf(", %d failed", failed)
+	}
+	fmt.Println()
+	return failed
+}
+
 func init() {
 	rootCmd.AddCommand(copyCmd)
 	copyCmd.PersistentFlags().String("src-org", "", "Specify the source organization")
 	copyCmd.PersistentFlags().String("dst-org", "", "Specify the destination organization")
 	copyCmd.PersistentFlags().String("src-ws", "", "Specify the source workspace")
-	copyCmd.PersistentFlags().String("dst-ws", "", "Specify the destination workspace")
-	_ = copyCmd.MarkPersistentFlagRequired("dst-ws")
+	copyCmd.PersistentFlags().StringSlice("dst-ws", []string{}, `Specify the destination workspace, as workspace or organization/workspace.
+This flag can be set multiple times`)
+	addWorkspaceSelectorFlags(copyCmd, true)
 	copyCmd.PersistentFlags().BoolP("replace", "r", false, "Specify whether to overwrite the existing variables or not")
+	addSensitiveValueFlags(copyCmd)
+	copyCmd.PersistentFlags().String("schema", "", "Specify a schema file the variables are checked against before any change")
 }
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestParseWorkspaceRef(t *testing.T) {
+	tests := []struct {
+		ref        string
+		wantOrg    string
+		wantWsName string
+	}{
+		{ref: "app-prod", wantOrg: "acme", wantWsName: "app-prod"},
+		{ref: "other-org/app-prod", wantOrg: "other-org", wantWsName: "app-prod"},
+		{ref: "other-org/app/prod", wantOrg: "other-org", wantWsName: "app/prod"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ref, func(t *testing.T) {
+			if orgName, wsName := parseWorkspaceRef(test.ref, "acme"); orgName != test.wantOrg || wsName != test.wantWsName {
+				t.Errorf("got %s and %s, want %s and %s", orgName, wsName, test.wantOrg, test.wantWsName)
+			}
+		})
+	}
+}
+
+func TestResolveCopyDestinations(t *testing.T) {
+	source := &tfe.Workspace{ID: "ws-1", Name: "app-dev"}
+	staging := &tfe.Workspace{ID: "ws-2", Name: "app-staging"}
+	prod := &tfe.Workspace{ID: "ws-3", Name: "app-prod"}
+	otherProd := &tfe.Workspace{ID: "ws-4", Name: "app-prod"}
+	workspaces := map[string][]*tfe.Workspace{
+		"acme":      {source, staging, prod},
+		"other-org": {otherProd},
+	}
+
+	tests := []struct {
+		name     string
+		refs     []string
+		selected []*tfe.Workspace
+		want     []copyDestination
+		wantErr  bool
+	}{
+		{
+			name: "workspaces of several organizations",
+			refs: []string{"app-staging", "other-org/app-prod"},
+			want: []copyDestination{{Organization: "acme", Workspace: staging}, {Organization: "other-org", Workspace: otherProd}},
+		},
+		{
+			name:     "duplicates are kept once",
+			refs:     []string{"app-prod", "acme/app-prod"},
+			selected: []*tfe.Workspace{staging, prod},
+			want:     []copyDestination{{Organization: "acme", Workspace: prod}, {Organization: "acme", Workspace: staging}},
+		},
+		{
+			name:     "the source is left out",
+			refs:     []string{"app-dev"},
+			selected: []*tfe.Workspace{source, staging},
+			want:     []copyDestination{{Organization: "acme", Workspace: staging}},
+		},
+		{
+			name: "nothing to copy to",
+			want: []copyDestination{},
+		},
+		{
+			name:    "unknown workspace",
+			refs:    []string{"other-org/app-staging"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listed := make([]string, 0)
+			listWorkspaces := func(orgName string) []*tfe.Workspace {
+				listed = append(listed, orgName)
+				return workspaces[orgName]
+			}
+
+			got, err := resolveCopyDestinations(test.refs, "acme", source.ID, listWorkspaces, test.selected)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+			// Each organization is listed once
+			seen := make(map[string]bool)
+			for _, orgName := range listed {
+				if seen[orgName] {
+					t.Errorf("organization %s was listed more than once", orgName)
+				}
+				seen[orgName] = true
+			}
+		})
+	}
+}
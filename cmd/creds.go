@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// credsCmd represents the creds command
+var credsCmd = &cobra.Command{
+	Use:   "creds",
+	Short: "Command to push provider credentials to a TF workspace",
+	Long: `Command used to read the credentials of a cloud provider on this machine and push them
+as sensitive environment variables to a TF workspace.
+
+For more information, please use:
+tfc-helper creds [aws/gcp/azure] -h`,
+}
+
+// pushCredentials pushes the provider credentials to the workspace set with the flags or the environment variables
+func pushCredentials(cmd *cobra.Command, variables []helper.NewVariable) {
+	wsName, orgName := getWorkspaceAndOrganization(cmd)
+	workspaceID := helper.GetWorkspaceID(orgName, wsName)
+
+	helper.PushVariables(workspaceID, variables)
+	for _, variable := range variables {
+		fmt.Printf("Pushed %s to workspace %s\n", variable.Key, wsName)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(credsCmd)
+}
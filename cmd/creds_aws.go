@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// credsAwsCmd represents the creds aws command
+var credsAwsCmd = &cobra.Command{
+	Use:   "aws",
+	Short: "Command to push the credentials of an AWS profile to a TF workspace",
+	Long: `Command used to read the credentials of a profile in ~/.aws/credentials and its region
+in ~/.aws/config, then push AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN (if any)
+and AWS_DEFAULT_REGION (if any) as sensitive environment variables.
+
+Examples:
+tfc-help creds aws --profile deploy -w ws-K33Rp -o big-corp
+tfc-help creds aws --profile deploy --region eu-west-1 -w ws-K33Rp -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		profile, _ := cmd.Flags().GetString("profile")
+		if profile == "" {
+			profile = os.Getenv("AWS_PROFILE")
+		}
+		if profile == "" {
+			profile = "default"
+		}
+
+		credentials, err := helper.GetAWSCredentials(profile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		// The region given in the command wins over the one in the profile
+		if region, _ := cmd.Flags().GetString("region"); region != "" {
+			credentials.Region = region
+		}
+
+		pushCredentials(cmd, credentials.Variables())
+	},
+}
+
+func init() {
+	credsCmd.AddCommand(credsAwsCmd)
+	credsAwsCmd.Flags().String("profile", "", "Specify the AWS profile (default is AWS_PROFILE or default)")
+	credsAwsCmd.Flags().String("region", "", "Specify the region pushed as AWS_DEFAULT_REGION instead of the profile region")
+}
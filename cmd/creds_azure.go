@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// credsAzureCmd represents the creds azure command
+var credsAzureCmd = &cobra.Command{
+	Use:   "azure",
+	Short: "Command to push Azure service principal credentials to a TF workspace",
+	Long: `Command used to read the credentials of a service principal and push ARM_CLIENT_ID,
+ARM_CLIENT_SECRET, ARM_TENANT_ID and ARM_SUBSCRIPTION_ID as environment variables.
+Only ARM_CLIENT_SECRET is sensitive.
+The credentials are read from an SDK auth file (az ad sp create-for-rbac --sdk-auth) given with
+--auth-file or AZURE_AUTH_LOCATION. Otherwise they are read from the az CLI profile of a service
+principal login (az login --service-principal) in ~/.azure or AZURE_CONFIG_DIR.
+
+Examples:
+tfc-help creds azure --auth-file sp.json -w ws-K33Rp -o big-corp
+tfc-help creds azure --auth-file sp.json --subscription 00000000-0000-0000-0000-000000000000 -w ws-K33Rp -o big-corp
+tfc-help creds azure --subscription production -w ws-K33Rp -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		authFile, _ := cmd.Flags().GetString("auth-file")
+		if authFile == "" {
+			authFile = os.Getenv("AZURE_AUTH_LOCATION")
+		}
+		subscription, _ := cmd.Flags().GetString("subscription")
+
+		var credentials helper.AzureCredentials
+		var err error
+		if authFile != "" {
+			credentials, err = helper.GetAzureCredentialsFromAuthFile(authFile, subscription)
+		} else {
+			configDir := os.Getenv("AZURE_CONFIG_DIR")
+			if configDir == "" {
+				homeDir, _ := os.UserHomeDir()
+				configDir = filepath.Join(homeDir, ".azure")
+			}
+			credentials, err = helper.GetAzureCredentialsFromProfile(configDir, subscription)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		pushCredentials(cmd, credentials.Variables())
+	},
+}
+
+func init() {
+	credsCmd.AddCommand(credsAzureCmd)
+	credsAzureCmd.Flags().String("auth-file", "", "Specify the SDK auth file of the service principal")
+	credsAzureCmd.Flags().String("subscription", "", `Specify the name or ID of the subscription in the az CLI profile (default is the default subscription).
+With an auth file, it has to be a subscription ID and replaces the one of the file`)
+}
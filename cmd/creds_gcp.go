@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// credsGcpCmd represents the creds gcp command
+var credsGcpCmd = &cobra.Command{
+	Use:   "gcp",
+	Short: "Command to push a GCP service account key to a TF workspace",
+	Long: `Command used to validate a service account key file, flatten it to a single line
+and push it as the sensitive GOOGLE_CREDENTIALS environment variable.
+Use --with-project to also push the project of the key as GOOGLE_PROJECT.
+
+Examples:
+tfc-help creds gcp --key-file sa.json -w ws-K33Rp -o big-corp
+tfc-help creds gcp --key-file sa.json --with-project -w ws-K33Rp -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyFile, _ := cmd.Flags().GetString("key-file")
+		withProject, _ := cmd.Flags().GetBool("with-project")
+
+		key, err := helper.GetGCPServiceAccountKey(keyFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		pushCredentials(cmd, key.Variables(withProject))
+	},
+}
+
+func init() {
+	credsCmd.AddCommand(credsGcpCmd)
+	credsGcpCmd.Flags().String("key-file", "", "Specify the service account key file")
+	_ = credsGcpCmd.MarkFlagRequired("key-file")
+	credsGcpCmd.Flags().Bool("with-project", false, "Specify whether to push the project of the key as GOOGLE_PROJECT")
+}
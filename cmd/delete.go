@@ -3,8 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
 	"tfc-helper/helper"
 
+	"github.com/hashicorp/go-tfe"
 	"github.com/spf13/cobra"
 )
 
@@ -18,7 +20,8 @@ flag to delete terraform variables or environment variables with -e.
 Examples:
 tfc-help delete --var some_variable=some_value -w ws-K33Rp -o big-corp
 tfc-help delete --var some_variable -w ws-K33Rp -o big-corp
-tfc-help delete -a -w ws-K33Rp -o big-corp`,
+tfc-help delete -a -w ws-K33Rp -o big-corp
+tfc-help delete --var some_variable --ws-match 'app-*-prod' -o big-corp`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Try to get workspace value from environment variable
 		// var workspaceName string
@@ -42,6 +45,11 @@ tfc-help delete -a -w ws-K33Rp -o big-corp`,
 		keyPairs, _ := cmd.Flags().GetStringSlice("var")
 		allVar, _ := cmd.Flags().GetBool("all")
 
+		if workspaces := selectWorkspaces(cmd, organizationName); workspaces != nil {
+			deleteInWorkspaces(cmd, workspaces, keyPairs, allVar)
+			return
+		}
+
 		workspaceID := helper.GetWorkspaceID(organizationName, workspaceName)
 
 		if allVar {
@@ -68,7 +76,65 @@ tfc-help delete -a -w ws-K33Rp -o big-corp`,
 	},
 }
 
+// deleteInWorkspaces deletes the variables in every selected workspace once the changes are confirmed
+func deleteInWorkspaces(cmd *cobra.Command, workspaces []*tfe.Workspace, keyPairs []string, allVar bool) {
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	keys := make([]string, 0)
+	for key := range helper.GetCommandValues(keyPairs) {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	plans := make([]workspaceChanges, 0, len(workspaces))
+	for _, result := range helper.ListVariablesInWorkspaces(workspaces, concurrency) {
+		if result.Err != nil {
+			fmt.Printf("Cannot list the variables of workspace %s: %s\n", result.Workspace.Name, result.Err)
+			os.Exit(1)
+		}
+
+		changes := make([]helper.Change, 0)
+		for _, variable := range result.Variables {
+			if allVar {
+				changes = append(changes, helper.Change{
+					Action:   helper.ActionDelete,
+					Variable: helper.NewVariable{ID: variable.ID, Key: variable.Key, Category: variable.Category},
+					Existing: variable,
+				})
+			}
+		}
+		if !allVar {
+			for _, key := range keys {
+				change := helper.Change{Action: helper.ActionSkip, Variable: helper.NewVariable{Key: key}, Reason: "does not exist"}
+				for _, variable := range result.Variables {
+					if variable.Key == key {
+						change = helper.Change{
+							Action:   helper.ActionDelete,
+							Variable: helper.NewVariable{ID: variable.ID, Key: variable.Key, Category: variable.Category},
+							Existing: variable,
+						}
+					}
+				}
+				changes = append(changes, change)
+			}
+		}
+		plans = append(plans, workspaceChanges{Workspace: result.Workspace, Changes: changes})
+	}
+
+	if !confirmWorkspaceChanges(cmd, plans) {
+		// The changes were not confirmed
+		if countChanges(plans) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	if failed := applyToWorkspaces(plans); failed > 0 {
+		fmt.Printf("\n%d change(s) failed\n", failed)
+		os.Exit(1)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(deleteCmd)
 	deleteCmd.PersistentFlags().BoolP("all", "a", false, "Specify whether to delete all variables")
+	addWorkspaceSelectorFlags(deleteCmd, true)
 }
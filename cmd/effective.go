@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// effectiveCmd represents the effective command
+var effectiveCmd = &cobra.Command{
+	Use:   "effective",
+	Short: "Command to show the variables a run of a TF workspace sees",
+	Long: `Command used to merge the variables of a workspace with the variable sets attached to it and the
+global variable sets of the organization, and to show the variables a run sees with the source each of them comes from.
+Workspace variables win over variable sets, sets attached to the workspace win over global sets, and between
+sets of the same kind the name first in lexical order wins. The sources whose variable is overridden are
+listed as shadowed. The values of sensitive variables are never shown.
+
+Examples:
+tfc-help effective -w ws-K33Rp -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		wsName, orgName := getWorkspaceAndOrganization(cmd)
+		workspaceID := helper.GetWorkspaceID(orgName, wsName)
+
+		variables, err := helper.EffectiveVariables(orgName, workspaceID)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "KEY\tCATEGORY\tSOURCE\tVALUE\tSHADOWED")
+		shadowed := 0
+		for _, effective := range variables {
+			value := effective.Variable.Value
+			if effective.Variable.Sensitive {
+				value = "(sensitive)"
+			}
+			if len(effective.Shadowed) > 0 {
+				shadowed++
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", effective.Variable.Key, effective.Variable.Category, effective.Source, value, strings.Join(effective.Shadowed, ", "))
+		}
+		writer.Flush()
+
+		if shadowed > 0 {
+			fmt.Printf("\n%d variable(s) of workspace %s are set in more than one source, only the first source is used\n", shadowed, wsName)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(effectiveCmd)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// extractCmd represents the extract command
+var extractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "Command to move the variables shared by several workspaces into a variable set",
+	Long: `Command used to scan the workspaces selected with --ws-match, --ws-tag or --ws-file and to find the
+non-sensitive variables every one of them has with the same value and attributes. These variables are proposed
+for a variable set. Variables also set in a variable set already used by one of the workspaces are left out,
+since removing them from the workspaces could let the other set win.
+
+With --apply, once confirmed, the variable set is created with the variables, attached to every workspace,
+and the copies of the variables in the workspaces are deleted.
+
+Examples:
+tfc-help extract --ws-match 'app-*-prod' -o big-corp
+tfc-help extract --ws-tag team:payments --set payments-shared --apply -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, orgName := getWorkspaceAndOrganization(cmd)
+		setName, _ := cmd.Flags().GetString("set")
+		apply, _ := cmd.Flags().GetBool("apply")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+		if apply && setName == "" {
+			fmt.Println("Please set the name of the variable set to create with the --set flag")
+			os.Exit(1)
+		}
+
+		workspaces := selectWorkspaces(cmd, orgName)
+		if len(workspaces) < 2 {
+			fmt.Println("Please select at least two workspaces with --ws-match, --ws-tag or --ws-file")
+			os.Exit(1)
+		}
+
+		results := helper.ListVariablesInWorkspaces(workspaces, concurrency)
+		for _, result := range results {
+			if result.Err != nil {
+				fmt.Printf("Cannot list the variables of workspace %s: %s\n", result.Workspace.Name, result.Err)
+				os.Exit(1)
+			}
+		}
+
+		// Keys already set in a variable set of the workspaces are left out
+		sets, err := helper.ListVariableSets(orgName)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		selected := make(map[string]bool)
+		for _, workspace := range workspaces {
+			selected[workspace.ID] = true
+		}
+		setKeys := make(map[string]string)
+		for _, set := range sets {
+			used := set.Global
+			for _, id := range set.WorkspaceIDs {
+				used = used || selected[id]
+			}
+			if !used {
+				continue
+			}
+			setVariables, err := helper.ListVariableSetVariables(set)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			for _, variable := range setVariables {
+				setKeys[string(variable.Category)+"/"+variable.Key] = set.Name
+			}
+		}
+
+		variables := make([]helper.NewVariable, 0)
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "KEY\tCATEGORY\tVALUE\tPROPOSAL")
+		for _, variable := range helper.CommonVariables(results) {
+			if otherSet, found := setKeys[string(variable.Category)+"/"+variable.Key]; found {
+				fmt.Fprintf(writer, "%s\t%s\t%s\tleft out, also set in the variable set %s\n", variable.Key, variable.Category, variable.Value, otherSet)
+				continue
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\tmove to the variable set\n", variable.Key, variable.Category, variable.Value)
+			variables = append(variables, variable)
+		}
+		writer.Flush()
+
+		if len(variables) == 0 {
+			fmt.Printf("\nNo variable can be moved out of the %d workspace(s)\n", len(workspaces))
+			return
+		}
+		if !apply {
+			fmt.Printf("\n%d variable(s) are shared by the %d workspace(s). Run again with --set and --apply to move them to a variable set\n", len(variables), len(workspaces))
+			return
+		}
+		if !confirm(cmd, fmt.Sprintf("\nMove %d variable(s) of %d workspace(s) to the new variable set %s?", len(variables), len(workspaces), setName)) {
+			fmt.Println("Nothing was changed")
+			os.Exit(1)
+		}
+
+		set, err := helper.CreateVariableSet(orgName, setName, fmt.Sprintf("Variables shared by %d workspaces", len(workspaces)), false)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Created the variable set %s (%s)\n", set.Name, set.ID)
+		if failed := applyToVariableSet(set, helper.PlanChanges(nil, variables, false)); failed > 0 {
+			fmt.Printf("\n%d variable(s) could not be added to the variable set %s, the workspaces were not changed\n", failed, set.Name)
+			os.Exit(1)
+		}
+
+		// The workspace copies are only deleted once the set is attached, so runs always see the variables
+		plans := make([]workspaceChanges, 0, len(results))
+		for _, result := range results {
+			if err := helper.AttachVariableSet(set, result.Workspace); err != nil {
+				fmt.Printf("%s, its variables were not deleted\n", err)
+				continue
+			}
+			changes := make([]helper.Change, 0, len(variables))
+			for _, variable := range variables {
+				for _, existing := range result.Variables {
+					if existing.Key == variable.Key && existing.Category == variable.Category {
+						changes = append(changes, helper.Change{
+							Action:   helper.ActionDelete,
+							Variable: helper.NewVariable{ID: existing.ID, Key: existing.Key, Category: existing.Category},
+							Existing: existing,
+						})
+					}
+				}
+			}
+			plans = append(plans, workspaceChanges{Workspace: result.Workspace, Changes: changes})
+		}
+
+		fmt.Println()
+		failed := applyToWorkspaces(plans)
+		if failed > 0 || len(plans) < len(results) {
+			fmt.Printf("\nThe variable set %s was not fully applied, please check the errors above\n", set.Name)
+			os.Exit(1)
+		}
+		fmt.Printf("\nMoved %d variable(s) of %d workspace(s) to the variable set %s\n", len(variables), len(workspaces), set.Name)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(extractCmd)
+	extractCmd.Flags().String("set", "", "Specify the name of the variable set to create")
+	extractCmd.Flags().Bool("apply", false, "Specify whether to create the variable set, attach it and delete the workspace copies")
+	addWorkspaceSelectorFlags(extractCmd, true)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// initVarsCmd represents the init-vars command
+var initVarsCmd = &cobra.Command{
+	Use:   "init-vars",
+	Short: "Command to write a variable file skeleton from the variable blocks of a module",
+	Long: `Command used to read the variable blocks of a Terraform module and to write a YAML manifest or a tfvars file
+with a variable for each of them, ready to be filled in and sent with update --var-file. Each variable starts with
+its default, or an empty value of its type when it is required, and keeps its description and sensitive flag.
+Lists, maps and objects are set as HCL. The types and the required variables are written as comments.
+The format is taken from the extension of --out (.yaml, .yml or .tfvars), the manifest is written to the standard
+output when --out is not set, and the errors always go to the standard error.
+
+Examples:
+tfc-help init-vars --dir ./infra --out prod.yaml
+tfc-help init-vars --dir ./infra --out prod.tfvars`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		out, _ := cmd.Flags().GetString("out")
+		force, _ := cmd.Flags().GetBool("force")
+
+		variables, err := helper.LoadModuleVariables(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if len(variables) == 0 {
+			fmt.Fprintf(os.Stderr, "No variable block found in %s\n", dir)
+			os.Exit(1)
+		}
+
+		var content []byte
+		switch {
+		case strings.HasSuffix(out, ".tfvars"):
+			content = helper.TfvarsSkeleton(variables)
+		case out == "", strings.HasSuffix(out, ".yaml"), strings.HasSuffix(out, ".yml"):
+			if content, err = helper.ManifestSkeleton(variables); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "Cannot write %s, the file should end with .yaml, .yml or .tfvars\n", out)
+			os.Exit(1)
+		}
+
+		if out == "" {
+			fmt.Print(string(content))
+			return
+		}
+		if _, err := os.Stat(out); err == nil && !force {
+			fmt.Fprintf(os.Stderr, "%s already exists, use --force to overwrite it\n", out)
+			os.Exit(1)
+		}
+		if err := ioutil.WriteFile(out, content, 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write %s: %s\n", out, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %d variable(s) of %s to %s\n", len(variables), dir, out)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initVarsCmd)
+	initVarsCmd.Flags().String("dir", ".", "Directory of the Terraform module")
+	initVarsCmd.Flags().String("out", "", "Specify the .yaml, .yml or .tfvars file to write (default is the standard output)")
+	initVarsCmd.Flags().Bool("force", false, "Overwrite the file set with --out when it exists")
+}
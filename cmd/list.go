@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/spf13/cobra"
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Command to list the variables of TF workspaces",
+	Long: `Command used to list the variables of a workspace, or of every workspace matching a pattern,
+having a tag or listed in a file. The values of sensitive variables are never shown.
+
+Examples:
+tfc-help list -w ws-K33Rp -o big-corp
+tfc-help list --ws-match 'app-*-prod' --ws-tag team:payments -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		wsName, orgName := getWorkspaceAndOrganization(cmd)
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+		workspaces := selectWorkspaces(cmd, orgName)
+		if workspaces == nil {
+			workspaces = []*tfe.Workspace{{ID: helper.GetWorkspaceID(orgName, wsName), Name: wsName}}
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "WORKSPACE\tKEY\tCATEGORY\tHCL\tSENSITIVE\tVALUE")
+		failed := 0
+		for _, result := range helper.ListVariablesInWorkspaces(workspaces, concurrency) {
+			if result.Err != nil {
+				fmt.Fprintf(writer, "%s\t\t\t\t\tfailed: %s\n", result.Workspace.Name, result.Err)
+				failed++
+				continue
+			}
+			for _, variable := range result.Variables {
+				value := variable.Value
+				if variable.Sensitive {
+					value = "(sensitive)"
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\t%t\t%t\t%s\n", result.Workspace.Name, variable.Key, variable.Category, variable.HCL, variable.Sensitive, value)
+			}
+		}
+		writer.Flush()
+
+		if failed > 0 {
+			fmt.Printf("\nCannot list the variables of %d workspace(s)\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	addWorkspaceSelectorFlags(listCmd, false)
+}
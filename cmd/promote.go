@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// promoteCmd represents the promote command
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Command to promote the variables of an environment to the next one",
+	Long: `Command used to copy the variables of a workspace forward to the workspace of the next environment,
+such as dev to staging, with the overrides and exclusions of the target environment. Workspaces can be given
+as workspace or organization/workspace. The differences are shown and confirmed before they are applied.
+
+Sensitive values cannot be read, so they are taken from --values-file, --value or the overrides. The other
+sensitive variables keep their values in the target workspace, or are skipped when it does not have them.
+Variables only in the target workspace are kept unless --prune is set. Excluded keys are never changed.
+
+An overrides file lists the keys that are never promoted and the variables that differ in the target environment:
+exclude:
+  - DEV_*
+variables:
+  - key: instance_count
+    value: "3"
+  - key: DB_PASSWORD
+    value: vault:secret/data/staging/db#password
+
+Examples:
+tfc-help promote --from app-dev --to app-staging --overrides staging.yaml -o big-corp
+tfc-help promote --from big-corp-dev/app --to big-corp/app --overrides prod.yaml --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		overridesFile, _ := cmd.Flags().GetString("overrides")
+		prune, _ := cmd.Flags().GetBool("prune")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		valuePairs, _ := cmd.Flags().GetStringSlice("value")
+		if !dryRun {
+			requireYesWithStdin(cmd, valuePairs)
+		}
+		_, orgName := getWorkspaceAndOrganization(cmd)
+
+		// The schema is read before any API call
+		schema := loadSchema(cmd)
+
+		fromOrgName, fromWsName := parseWorkspaceRef(from, orgName)
+		toOrgName, toWsName := parseWorkspaceRef(to, orgName)
+		fromWorkspaceID := helper.GetWorkspaceID(fromOrgName, fromWsName)
+		toWorkspaceID := helper.GetWorkspaceID(toOrgName, toWsName)
+
+		overrides := helper.PromotionOverrides{}
+		if overridesFile != "" {
+			var err error
+			if overrides, err = helper.LoadPromotionOverrides(overridesFile); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+
+		// The API never returns sensitive values, the supplied ones are used when there are
+		valuesFiles, _ := cmd.Flags().GetStringSlice("values-file")
+		values, err := helper.LoadValues(valuesFiles, valuePairs)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		sourceVariables := getSourceVariables(fromWorkspaceID)
+		for i, variable := range sourceVariables {
+			if variable.Sensitive && variable.Value == "" {
+				sourceVariables[i].Value = values[variable.Key]
+			}
+		}
+
+		variables, err := helper.CheckSecrets(overrides.Apply(sourceVariables, getVariableDefaults(cmd)))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		targetVariables := helper.ListAllVariables(toWorkspaceID)
+		targetKeys := make([]string, 0, len(targetVariables))
+		for _, variable := range targetVariables {
+			targetKeys = append(targetKeys, variable.Key)
+		}
+		checkSchema(schema, variables, targetKeys)
+
+		changes := make([]helper.Change, 0)
+		toApply := 0
+		for _, change := range helper.PlanChanges(targetVariables, variables, prune) {
+			if change.Action == helper.ActionDelete && overrides.Excludes(change.Variable.Key) {
+				continue
+			}
+			if change.Action != helper.ActionSkip {
+				toApply++
+			}
+			changes = append(changes, change)
+		}
+		if len(changes) == 0 {
+			fmt.Printf("Workspace %s/%s already matches workspace %s/%s\n", toOrgName, toWsName, fromOrgName, fromWsName)
+			return
+		}
+
+		fmt.Printf("Promoting workspace %s/%s to workspace %s/%s\n\n", fromOrgName, fromWsName, toOrgName, toWsName)
+		printPromotionDiff(changes)
+		if dryRun || toApply == 0 {
+			return
+		}
+		if !confirm(cmd, fmt.Sprintf("\nApply %d change(s) to workspace %s?", toApply, toWsName)) {
+			fmt.Println("Nothing was changed")
+			os.Exit(1)
+		}
+
+		fmt.Println()
+		if failed := applyChanges(toWorkspaceID, changes, false); failed > 0 {
+			fmt.Printf("\nFailed to apply %d of %d change(s) to workspace %s\n", failed, toApply, toWsName)
+			os.Exit(1)
+		}
+	},
+}
+
+// printPromotionDiff shows the current and the promoted value of every change. Sensitive values are never shown
+func printPromotionDiff(changes []helper.Change) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "KEY\tCATEGORY\tACTION\tCURRENT\tPROMOTED")
+	for _, change := range changes {
+		current := ""
+		if change.Existing != nil {
+			current = change.Existing.Value
+			if change.Existing.Sensitive {
+				current = "(sensitive)"
+			}
+		}
+
+		promoted := change.Variable.Value
+		switch {
+		case change.Action == helper.ActionDelete:
+			promoted = ""
+		case change.Action == helper.ActionSkip:
+			promoted = change.Reason
+		case change.Variable.Sensitive && change.Variable.Value == "":
+			promoted = "(sensitive, kept)"
+		case change.Variable.Sensitive:
+			promoted = "(sensitive)"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", change.Variable.Key, change.Variable.Category, change.Action, current, promoted)
+	}
+	writer.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+	promoteCmd.Flags().String("from", "", "Specify the workspace to promote, as workspace or organization/workspace")
+	_ = promoteCmd.MarkFlagRequired("from")
+	promoteCmd.Flags().String("to", "", "Specify the workspace of the next environment, as workspace or organization/workspace")
+	_ = promoteCmd.MarkFlagRequired("to")
+	promoteCmd.Flags().String("overrides", "", "Specify the file with the overrides and exclusions of the target environment")
+	promoteCmd.Flags().Bool("prune", false, "Specify whether to delete the variables that are only in the target workspace")
+	promoteCmd.Flags().Bool("dry-run", false, "Specify whether to only show the differences without applying them")
+	promoteCmd.Flags().Bool("yes", false, "Apply the changes without asking for confirmation")
+	promoteCmd.Flags().String("schema", "", "Specify a schema file the promoted variables are checked against before any change")
+	addSensitiveValueFlags(promoteCmd)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Command to restore the variables of a workspace from a backup",
+	Long: `Command used to bring the variables of a workspace back to a backup made with the backup command.
+Missing variables are created and changed variables are updated or recreated. The variables that are not
+in the backup are only deleted with --prune. Sensitive variables saved without their values keep
+their current values, or are skipped when they do not exist anymore.
+The changes are shown and have to be confirmed before they are applied, unless --yes is set.
+The backup is decrypted with the age identities of --age-key-file, SOPS_AGE_KEY_FILE or SOPS_AGE_KEY.
+
+Examples:
+tfc-helper restore --in test.age -w test --dry-run
+tfc-helper restore --in test.age -w test --prune`,
+	Run: func(cmd *cobra.Command, args []string) {
+		wsName, orgName := getWorkspaceAndOrganization(cmd)
+		in, _ := cmd.Flags().GetString("in")
+		prune, _ := cmd.Flags().GetBool("prune")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		backup, err := helper.ReadBackup(in)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Restoring the backup of workspace %s/%s made at %s\n", backup.Organization, backup.Workspace, backup.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+
+		workspaceID := helper.GetWorkspaceID(orgName, wsName)
+		changes := helper.PlanChanges(helper.ListAllVariables(workspaceID), backup.NewVariables(), prune)
+		if len(changes) == 0 {
+			fmt.Printf("Workspace %s already matches the backup\n", wsName)
+			return
+		}
+
+		applyChanges(workspaceID, changes, true)
+		if dryRun {
+			return
+		}
+		pending := 0
+		for _, change := range changes {
+			if change.Action != helper.ActionSkip {
+				pending++
+			}
+		}
+		if pending == 0 {
+			fmt.Println("\nNothing to change")
+			return
+		}
+		if !confirm(cmd, fmt.Sprintf("\nApply the changes to workspace %s?", wsName)) {
+			fmt.Println("Nothing was changed")
+			os.Exit(1)
+		}
+		fmt.Println()
+
+		if failed := applyChanges(workspaceID, changes, dryRun); failed > 0 {
+			fmt.Printf("\nFailed to apply %d of %d change(s) to workspace %s\n", failed, len(changes), wsName)
+			os.Exit(1)
+		}
+	},
+}
+
+// applyChanges applies the changes to a workspace one by one and prints the result of each of them.
+// With dryRun, the changes are only printed. It returns the number of changes that failed
+func applyChanges(workspaceID string, changes []helper.Change, dryRun bool) int {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "KEY\tCATEGORY\tACTION\tRESULT")
+	failed := 0
+	for _, change := range changes {
+		result := "done"
+		switch {
+		case change.Action == helper.ActionSkip:
+			result = change.Reason
+		case dryRun:
+			result = "planned"
+		default:
+			if err := helper.ApplyChange(workspaceID, change); err != nil {
+				result = fmt.Sprintf("failed: %s", err)
+				failed++
+			}
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", change.Variable.Key, change.Variable.Category, change.Action, result)
+	}
+	writer.Flush()
+	return failed
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().String("in", "", "Specify the encrypted backup file to restore")
+	_ = restoreCmd.MarkFlagRequired("in")
+	restoreCmd.Flags().Bool("prune", false, "Specify whether to delete the variables that are not in the backup")
+	restoreCmd.Flags().Bool("dry-run", false, "Specify whether to only show the changes without applying them")
+	restoreCmd.Flags().Bool("yes", false, "Apply the changes without asking for confirmation")
+}
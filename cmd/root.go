@@ -19,6 +19,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"tfc-helper/helper"
 
 	"github.com/spf13/cobra"
 
@@ -66,13 +67,51 @@ func init() {
 	rootCmd.PersistentFlags().StringP("workspace", "w", "", "Specify the name of the workspace")
 	rootCmd.PersistentFlags().StringP("organization", "o", "", "Specify the name of the organization")
 	rootCmd.PersistentFlags().StringSlice("var", []string{}, `Key value pair to put in terraform cloud.
-This flag can be set multiple times and can multiple values with comma separated`)
+This flag can be set multiple times and can multiple values with comma separated.
+The value can be read from a file with key=@path, from an environment variable with key=env:NAME,
+from a Vault KV secret with key=vault:path#field or from the standard input with key=-`)
 	rootCmd.PersistentFlags().StringP("description", "d", "", "Specify the description for the variable")
 	rootCmd.PersistentFlags().BoolP("terraform", "t", false, "Specify whether values are terraform variable")
 	rootCmd.PersistentFlags().Bool("hcl", false, "Specify whether the values are in HCL format")
 	rootCmd.PersistentFlags().BoolP("sensitive", "s", false, "Specify whether the values are sensitive")
 	rootCmd.PersistentFlags().BoolP("keep", "k", false, "Specify whether to keep the original value of the variable")
 	rootCmd.PersistentFlags().BoolP("env", "e", false, "Specify whether to grab the environment variables starting with 'TF_VAR_' from the host")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Specify the config file (default is $HOME/.tfc-helper.yaml)")
+	rootCmd.PersistentFlags().String("ca-cert", "", "Specify the CA bundle used to verify the Terraform Enterprise certificate")
+	rootCmd.PersistentFlags().String("client-cert", "", "Specify the client certificate used to authenticate to Terraform Enterprise")
+	rootCmd.PersistentFlags().String("client-key", "", "Specify the key of the client certificate")
+	rootCmd.PersistentFlags().String("proxy", "", "Specify the HTTP proxy used to reach Terraform Cloud")
+	rootCmd.PersistentFlags().Bool("insecure-skip-verify", false, "Skip TLS certificate verification. Only use this against lab instances")
+	rootCmd.PersistentFlags().String("age-key-file", "", "Specify the file with the age identities used to decrypt age and SOPS variable files (default is the SOPS key file)")
+	rootCmd.PersistentFlags().String("secret-policy", helper.SecretPolicyRefuse, `Specify what to do with variables that look like secrets but are not sensitive.
+refuse stops before any change, force marks them as sensitive, which cannot be undone,
+warn sends them unchanged with a warning and off disables the detection`)
+	rootCmd.PersistentFlags().StringSlice("secret-patterns", helper.DefaultSecretPatterns, "Specify the key patterns of the variables that are secrets")
+	rootCmd.PersistentFlags().Float64("secret-entropy", 4.5, `Specify the entropy in bits per character above which a value looks like a secret. 0 disables the check.
+Values too short to reach it need 90% of the highest entropy their length allows`)
+	rootCmd.PersistentFlags().StringSlice("allow-plaintext", []string{}, "Specify the keys that are never treated as secrets, ignoring the case")
+	rootCmd.PersistentFlags().Bool("read-only", false, "Refuse every change to Terraform Cloud. Commands that only read keep working")
+	rootCmd.PersistentFlags().String("audit-log", "", "Specify the file every change is recorded to as JSON lines. Use '-' to print the records")
+
+	// Settings that can also be set in the config file
+	for _, name := range []string{"ca-cert", "client-cert", "client-key", "proxy", "insecure-skip-verify", "age-key-file",
+		"secret-policy", "secret-patterns", "secret-entropy", "allow-plaintext", "read-only", "audit-log"} {
+		_ = viper.BindPFlag(name, rootCmd.PersistentFlags().Lookup(name))
+	}
+}
+
+// getWorkspaceAndOrganization gets the workspace and organization names from the flags first then from the environment variables
+func getWorkspaceAndOrganization(cmd *cobra.Command) (string, string) {
+	wsName, _ := cmd.Flags().GetString("workspace")
+	if wsName == "" {
+		wsName = os.Getenv(WorkspaceVar)
+	}
+
+	orgName, _ := cmd.Flags().GetString("organization")
+	if orgName == "" {
+		orgName = os.Getenv(OrgVar)
+	}
+	return wsName, orgName
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -95,8 +134,35 @@ func initConfig() {
 
 	viper.AutomaticEnv() // read in environment variables that match
 
-	// If a config file is found, read it in.
+	// If a config file is found, read it in. The message goes to stderr to keep the output of list commands clean
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+	}
+
+	helper.SetTransportConfig(helper.TransportConfig{
+		CACert:             viper.GetString("ca-cert"),
+		ClientCert:         viper.GetString("client-cert"),
+		ClientKey:          viper.GetString("client-key"),
+		Proxy:              viper.GetString("proxy"),
+		InsecureSkipVerify: viper.GetBool("insecure-skip-verify"),
+	})
+
+	helper.SetAgeKeyFile(viper.GetString("age-key-file"))
+
+	if err := helper.SetSecretDetection(helper.SecretDetection{
+		Policy:         viper.GetString("secret-policy"),
+		Patterns:       viper.GetStringSlice("secret-patterns"),
+		MinEntropy:     viper.GetFloat64("secret-entropy"),
+		AllowPlaintext: viper.GetStringSlice("allow-plaintext"),
+	}); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	helper.SetReadOnly(viper.GetBool("read-only"))
+
+	if err := helper.SetAuditLog(viper.GetString("audit-log")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// rotateCmd represents the rotate command
+var rotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Command to rotate the value of a variable in every workspace of an organization",
+	Long: `Command used to find every workspace of an organization holding a variable and to update
+its value in place. The category, description, HCL flag and sensitivity of each variable are kept.
+The matched workspaces are listed and the rotation has to be confirmed, unless --yes is set.
+Nothing is rotated when the variables of a workspace cannot be listed.
+The value can be a literal or a reference: @path, env:NAME, vault:path#field or - for the standard input.
+Values read from Vault are always sensitive.
+
+Examples:
+tfc-help rotate --key DB_PASSWORD --value-from vault:secret/data/db#password -o acme
+tfc-help rotate --key DB_PASSWORD --value-from env:NEW_DB_PASSWORD -o acme --queue-runs`,
+	Run: func(cmd *cobra.Command, args []string) {
+		key, _ := cmd.Flags().GetString("key")
+		valueFrom, _ := cmd.Flags().GetString("value-from")
+		queueRuns, _ := cmd.Flags().GetBool("queue-runs")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		_, orgName := getWorkspaceAndOrganization(cmd)
+
+		// The confirmation is read from the standard input too
+		if yes, _ := cmd.Flags().GetBool("yes"); valueFrom == "-" && !yes {
+			fmt.Println("Please set --yes when the value is read from the standard input")
+			os.Exit(1)
+		}
+
+		value, err := helper.ResolveValue(valueFrom)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if value == "" {
+			fmt.Printf("The new value of %s is empty, nothing was rotated\n", key)
+			os.Exit(1)
+		}
+		// Values read from a secret store are always sensitive
+		forceSensitive := helper.IsSensitiveReference(valueFrom)
+
+		// A workspace that cannot be read could still hold the old value, so nothing is rotated
+		results := helper.ListVariablesInWorkspaces(helper.ListAllWorkspaces(orgName), concurrency)
+		failed := 0
+		for _, result := range results {
+			if result.Err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot list the variables of workspace %s: %s\n", result.Workspace.Name, result.Err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "%d workspace(s) could not be read, nothing was rotated\n", failed)
+			os.Exit(1)
+		}
+
+		targets := helper.FindRotationTargets(results, key, value, forceSensitive)
+		if len(targets) == 0 {
+			fmt.Printf("No workspace in organization %s has the variable %s\n", orgName, key)
+			return
+		}
+
+		variablesToRotate := make([]helper.NewVariable, 0, len(targets))
+		for _, target := range targets {
+			variablesToRotate = append(variablesToRotate, target.Variable)
+		}
+		variablesToRotate, err = helper.CheckSecrets(variablesToRotate)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "WORKSPACE\tCATEGORY\tSENSITIVE")
+		for i, variable := range variablesToRotate {
+			fmt.Fprintf(writer, "%s\t%s\t%t\n", targets[i].Workspace.Name, variable.Category, variable.Sensitive)
+		}
+		writer.Flush()
+		if !confirm(cmd, fmt.Sprintf("\nRotate %s in %d workspace(s)?", key, len(variablesToRotate))) {
+			fmt.Println("Nothing was changed")
+			os.Exit(1)
+		}
+
+		writer = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "WORKSPACE\tCATEGORY\tRESULT\tRUN")
+		for i, variable := range variablesToRotate {
+			workspace := targets[i].Workspace
+			result := rotateVariable(workspace.ID, variable)
+			run := ""
+			if result == "updated" {
+				if queueRuns {
+					runID, err := helper.QueueRun(workspace.ID, fmt.Sprintf("Queued by tfc-helper after rotating %s", key))
+					run = runID
+					if err != nil {
+						run = fmt.Sprintf("failed: %s", err)
+					}
+				}
+			} else {
+				failed++
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", workspace.Name, variable.Category, result, run)
+		}
+		writer.Flush()
+
+		if failed > 0 {
+			fmt.Printf("\nFailed to rotate %s in %d of %d workspace(s)\n", key, failed, len(variablesToRotate))
+			os.Exit(1)
+		}
+	},
+}
+
+// rotateVariable updates the variable in place. The category and sensitivity are kept, so it is never
+// deleted and created again. It returns the result shown in the report
+func rotateVariable(workspaceID string, variable helper.NewVariable) string {
+	if err := helper.UpdateVariableE(workspaceID, variable); err != nil {
+		return fmt.Sprintf("failed: %s", err)
+	}
+	return "updated"
+}
+
+func init() {
+	rootCmd.AddCommand(rotateCmd)
+	rotateCmd.Flags().String("key", "", "Specify the key of the variable to rotate")
+	_ = rotateCmd.MarkFlagRequired("key")
+	rotateCmd.Flags().String("value-from", "", "Specify the new value or a reference to it")
+	_ = rotateCmd.MarkFlagRequired("value-from")
+	rotateCmd.Flags().Bool("queue-runs", false, "Specify whether to queue a run in every workspace where the variable was rotated")
+	addConcurrencyFlag(rotateCmd)
+	rotateCmd.Flags().Bool("yes", false, "Rotate the variable in the matched workspaces without asking for confirmation")
+}
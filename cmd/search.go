@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Command to find the variables of an organization by key or value",
+	Long: `Command used to scan the variables of every workspace of an organization and list the ones
+whose key matches a glob pattern and, with --value-regex, whose value matches a regular expression.
+The values of sensitive variables cannot be read, so they never match --value-regex.
+
+Examples:
+tfc-help search -o acme --key 'AWS_*'
+tfc-help search -o acme --value-regex 'AKIA[0-9A-Z]{16}' --output json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyPattern, _ := cmd.Flags().GetString("key")
+		valuePattern, _ := cmd.Flags().GetString("value-regex")
+		output, _ := cmd.Flags().GetString("output")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		_, orgName := getWorkspaceAndOrganization(cmd)
+
+		if output != "table" && output != "json" {
+			fmt.Fprintf(os.Stderr, "Invalid output %q, it should be table or json\n", output)
+			os.Exit(1)
+		}
+		if _, err := path.Match(keyPattern, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid key pattern %q: %s\n", keyPattern, err)
+			os.Exit(1)
+		}
+		var valueRegex *regexp.Regexp
+		if valuePattern != "" {
+			var err error
+			if valueRegex, err = regexp.Compile(valuePattern); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid value regex: %s\n", err)
+				os.Exit(1)
+			}
+		}
+
+		results := helper.ListVariablesInWorkspaces(helper.ListAllWorkspaces(orgName), concurrency)
+		failed := 0
+		for _, result := range results {
+			if result.Err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot list the variables of workspace %s: %s\n", result.Workspace.Name, result.Err)
+				failed++
+			}
+		}
+
+		writeSearchMatches(os.Stdout, helper.SearchVariables(results, keyPattern, valueRegex), output, orgName)
+
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "%d workspace(s) could not be searched\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+// writeSearchMatches writes the matches as a JSON array or as a table followed by their count
+func writeSearchMatches(out io.Writer, matches []helper.VariableMatch, output string, orgName string) {
+	if output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(matches)
+		return
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "WORKSPACE\tKEY\tCATEGORY\tSENSITIVE")
+	for _, match := range matches {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%t\n", match.Workspace, match.Key, match.Category, match.Sensitive)
+	}
+	writer.Flush()
+	fmt.Fprintf(out, "\n%d variable(s) found in organization %s\n", len(matches), orgName)
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().String("key", "*", "Specify the glob pattern the keys have to match, such as 'AWS_*'")
+	searchCmd.Flags().String("value-regex", "", "Specify the regular expression the values have to match. Sensitive values never match")
+	searchCmd.Flags().String("output", "table", "Specify the output format, table or json")
+	addConcurrencyFlag(searchCmd)
+}
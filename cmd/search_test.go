@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"tfc-helper/helper"
+)
+
+func TestWriteSearchMatches(t *testing.T) {
+	matches := []helper.VariableMatch{
+		{Workspace: "app-prod", WorkspaceID: "ws-1", Key: "AWS_ACCESS_KEY_ID", Category: "env"},
+		{Workspace: "app-staging", WorkspaceID: "ws-2", Key: "db_password", Category: "terraform", Sensitive: true},
+	}
+
+	tests := []struct {
+		name    string
+		output  string
+		matches []helper.VariableMatch
+		want    string
+	}{
+		{
+			name:    "json",
+			output:  "json",
+			matches: matches,
+			want: `[
+  {
+    "workspace": "app-prod",
+    "workspace_id": "ws-1",
+    "key": "AWS_ACCESS_KEY_ID",
+    "category": "env",
+    "sensitive": false
+  },
+  {
+    "workspace": "app-staging",
+    "workspace_id": "ws-2",
+    "key": "db_password",
+    "category": "terraform",
+    "sensitive": true
+  }
+]
+`,
+		},
+		{
+			name:    "json without match",
+			output:  "json",
+			matches: []helper.VariableMatch{},
+			want:    "[]\n",
+		},
+		{
+			name:    "table",
+			output:  "table",
+			matches: matches,
+			want: `WORKSPACE    KEY                CATEGORY   SENSITIVE
+app-prod     AWS_ACCESS_KEY_ID  env        false
+app-staging  db_password        terraform  true
+
+2 variable(s) found in organization acme
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			writeSearchMatches(&out, test.matches, test.output, "acme")
+			if got := out.String(); got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/spf13/cobra"
+)
+
+// workspaceChanges has the changes planned in a workspace
+type workspaceChanges struct {
+	Workspace *tfe.Workspace
+	Changes   []helper.Change
+}
+
+// addWorkspaceSelectorFlags adds the flags selecting several workspaces instead of a single -w
+func addWorkspaceSelectorFlags(command *cobra.Command, confirmation bool) {
+	command.Flags().StringSlice("ws-match", []string{}, "Select the workspaces whose name matches a glob pattern such as 'app-*-prod'. Can be set multiple times")
+	command.Flags().StringSlice("ws-tag", []string{}, "Select the workspaces having a tag such as team:payments. Workspaces need every tag given")
+	command.Flags().String("ws-file", "", "Select the workspaces listed in a file, one name per line")
+	addConcurrencyFlag(command)
+	if confirmation {
+		command.Flags().Bool("yes", false, "Apply the changes to the selected workspaces without asking for confirmation")
+	}
+}
+
+// addConcurrencyFlag adds the flag limiting the number of workspaces read at the same time
+func addConcurrencyFlag(command *cobra.Command) {
+	command.Flags().Int("concurrency", helper.DefaultConcurrency, "Specify the number of workspaces read at the same time")
+}
+
+// workspaceSelector gets the workspace selector from the flags
+func workspaceSelector(cmd *cobra.Command) helper.WorkspaceSelector {
+	patterns, _ := cmd.Flags().GetStringSlice("ws-match")
+	tags, _ := cmd.Flags().GetStringSlice("ws-tag")
+	file, _ := cmd.Flags().GetString("ws-file")
+	return helper.WorkspaceSelector{Patterns: patterns, Tags: tags, File: file}
+}
+
+// selectWorkspaces gets the workspaces matching the selector flags. It returns nil when no selector flag is set
+func selectWorkspaces(cmd *cobra.Command, orgName string) []*tfe.Workspace {
+	selector := workspaceSelector(cmd)
+	if !selector.IsSet() {
+		return nil
+	}
+
+	workspaces, err := helper.SelectWorkspaces(orgName, selector)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if len(workspaces) == 0 {
+		fmt.Printf("No workspace of organization %s matches the selection\n", orgName)
+		os.Exit(1)
+	}
+	return workspaces
+}
+
+// stdinReader reads the answers to the questions asked by confirm
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// stdinConfirmationMessage is shown when the answer to a question cannot be read since a value was read from the standard input
+const stdinConfirmationMessage = "Please set --yes when a value is read from the standard input, the confirmation cannot be read from it too"
+
+// confirm asks a yes or no question, unless --yes is set
+func confirm(cmd *cobra.Command, question string) bool {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return true
+	}
+	// The standard input was read to its end, an answer would always be empty
+	if helper.StandardInputUsed() {
+		fmt.Println(stdinConfirmationMessage)
+		os.Exit(1)
+	}
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := stdinReader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// requireYesWithStdin exits before anything is read or changed when one of the KEY=value pairs reads its value
+// from the standard input and --yes is not set, since the confirmation is read from the standard input too
+func requireYesWithStdin(cmd *cobra.Command, valuePairs []string) {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes || !helper.ReadsStandardInput(valuePairs) {
+		return
+	}
+	fmt.Println(stdinConfirmationMessage)
+	os.Exit(1)
+}
+
+// countChanges counts the changes planned in the workspaces, skipped changes left out
+func countChanges(plans []workspaceChanges) int {
+	total := 0
+	for _, plan := range plans {
+		for _, change := range plan.Changes {
+			if change.Action != helper.ActionSkip {
+				total++
+			}
+		}
+	}
+	return total
+}
+
+// confirmWorkspaceChanges shows the number of changes planned in each workspace and asks for confirmation.
+// It returns false when there is nothing to do or when the changes are not confirmed, countChanges tells which
+func confirmWorkspaceChanges(cmd *cobra.Command, plans []workspaceChanges) bool {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "WORKSPACE\tCHANGES")
+	for _, plan := range plans {
+		counts := make(map[string]int)
+		for _, change := range plan.Changes {
+			counts[change.Action]++
+		}
+		summary := make([]string, 0)
+		for _, action := range []string{helper.ActionCreate, helper.ActionUpdate, helper.ActionRecreate, helper.ActionDelete, helper.ActionSkip} {
+			if counts[action] > 0 {
+				summary = append(summary, fmt.Sprintf("%d to %s", counts[action], action))
+			}
+		}
+		if len(summary) == 0 {
+			summary = append(summary, "none")
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", plan.Workspace.Name, strings.Join(summary, ", "))
+	}
+	writer.Flush()
+
+	total := countChanges(plans)
+	if total == 0 {
+		fmt.Println("\nNothing to change")
+		return false
+	}
+	if !confirm(cmd, fmt.Sprintf("\nApply %d change(s) to %d workspace(s)?", total, len(plans))) {
+		fmt.Println("Nothing was changed")
+		return false
+	}
+	return true
+}
+
+// applyToWorkspaces applies the changes of every workspace and prints a combined report.
+// It returns the number of changes that failed
+func applyToWorkspaces(plans []workspaceChanges) int {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "WORKSPACE\tKEY\tCATEGORY\tACTION\tRESULT")
+	failed := 0
+	for _, plan := range plans {
+		for _, change := range plan.Changes {
+			result := "done"
+			if change.Action == helper.ActionSkip {
+				result = change.Reason
+			} else if err := helper.ApplyChange(plan.Workspace.ID, change); err != nil {
+				result = fmt.Sprintf("failed: %s", err)
+				failed++
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", plan.Workspace.Name, change.Variable.Key, change.Variable.Category, change.Action, result)
+		}
+	}
+	writer.Flush()
+	return failed
+}
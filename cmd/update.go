@@ -35,7 +35,18 @@ but the updated value is another category:
 tfc-help update --env -r -w ws-K33Rp -o big-corp
 
 - Create/Update only new variables found in the current workspace:
-tfc-help update --env -w ws-K33Rp -o big-corp`,
+tfc-help update --env -w ws-K33Rp -o big-corp
+
+- Create/Update variables from files. Files encrypted with age or SOPS are decrypted in memory
+with the key in --age-key-file (or SOPS_AGE_KEY_FILE) and their variables are always sensitive:
+tfc-help update --var-file prod.tfvars --var-file secrets.env.age -w ws-K33Rp -o big-corp
+
+- Create/Update variables in every workspace matching a pattern, having a tag or listed in a file.
+The changes planned in each workspace are shown and confirmed before they are applied, unless --yes is set:
+tfc-help update --var-file shared.env --ws-match 'app-*-prod' --ws-tag team:payments -o big-corp
+
+- Check the variables against a schema before sending them, see the validate command for the schema format:
+tfc-help update --var-file prod.tfvars --schema schema.yaml -w ws-K33Rp -o big-corp`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Try to get value from command line first then try the environment variable
 		workspaceName, _ = cmd.Flags().GetString("workspace")
@@ -51,108 +62,100 @@ tfc-help update --env -w ws-K33Rp -o big-corp`,
 			organizationName = os.Getenv(OrgVar)
 		}
 
-		keyPairs, _ := cmd.Flags().GetStringSlice("var")
-		variableDescription, _ := cmd.Flags().GetString("description")
-		isTVar, _ := cmd.Flags().GetBool("terraform")
-		hcl, _ := cmd.Flags().GetBool("hcl")
-		sensitive, _ := cmd.Flags().GetBool("sensitive")
 		shouldReplace, _ := cmd.Flags().GetBool("replace")
 		keepValue, _ := cmd.Flags().GetBool("keep")
-		env, _ := cmd.Flags().GetBool("env")
 
-		// Get the correct category type
-		category := tfe.CategoryEnv
-		if isTVar {
-			category = tfe.CategoryTerraform
+		// Updates of several workspaces are confirmed
+		if workspaceSelector(cmd).IsSet() {
+			keyPairs, _ := cmd.Flags().GetStringSlice("var")
+			requireYesWithStdin(cmd, keyPairs)
 		}
 
-		workspaceID := helper.GetWorkspaceID(organizationName, workspaceName)
+		// The schema is read before any API call
+		schema := loadSchema(cmd)
 
-		var valueToSend map[string]string
+		// defaults stores the attributes given in the command
+		defaults := getVariableDefaults(cmd)
+		variablesToSend, err := helper.CheckSecrets(getVariablesToSend(cmd, defaults))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 
-		if env && len(keyPairs) > 0 {
-			valueToSend = helper.GetTFValues(isTVar)
-			commandValues := helper.GetCommandValues(keyPairs)
-			for key := range valueToSend {
-				for commandKey, commandValue := range commandValues {
-					// Check if there are duplicated key, values in environment win
-					if key == commandKey {
-						continue
-					} else {
-						valueToSend[commandKey] = commandValue
-					}
-				}
+		if workspaces := selectWorkspaces(cmd, organizationName); workspaces != nil {
+			updateWorkspaces(cmd, workspaces, variablesToSend, schema, shouldReplace, keepValue)
+			return
+		}
+
+		workspaceID := helper.GetWorkspaceID(organizationName, workspaceName)
 
+		// Check the variables as they will be written, -k keeps the existing values
+		existingVariables := helper.ListAllVariables(workspaceID)
+		existingKeys := make([]string, 0, len(existingVariables))
+		existingByKey := make(map[string]*tfe.Variable)
+		for _, variable := range existingVariables {
+			existingKeys = append(existingKeys, variable.Key)
+			existingByKey[variable.Key] = variable
+		}
+		variablesToCheck := make([]helper.NewVariable, 0, len(variablesToSend))
+		for _, variable := range variablesToSend {
+			if existing, found := existingByKey[variable.Key]; keepValue && found {
+				variable.Value = existing.Value
 			}
-			// If only flag -e is set, then grab all variables in the current environment
-		} else if env {
-			valueToSend = helper.GetTFValues(isTVar)
-			// If only flag -v is set, then grab all variables in the command line
-		} else {
-			valueToSend = helper.GetCommandValues(keyPairs)
+			variablesToCheck = append(variablesToCheck, variable)
 		}
+		checkSchema(schema, variablesToCheck, existingKeys)
 
 		var wg sync.WaitGroup
+		failed := false
 
-		// Loop through all values passed from the command line
-		for newVariableName, newVariableValue := range valueToSend {
+		// Loop through all values passed from the command line and the variable files
+		for _, newVariable := range variablesToSend {
 			wg.Add(1)
 
 			// Try to get the variable ID
-			variable, error := helper.GetVar(workspaceID, newVariableName)
+			variable, found := existingByKey[newVariable.Key]
 
-			/* If error, meaning the variable does not exist, proceed to create one based on
+			/* If not found, meaning the variable does not exist, proceed to create one based on
 			current value */
-			if error != nil {
-				// newVariable stores the value given in the command
-				newVariable := helper.NewVariable{
-					ID:          "",
-					Key:         newVariableName,
-					Value:       newVariableValue,
-					Description: variableDescription,
-					Category:    category,
-					HCL:         hcl,
-					Sensitive:   sensitive,
-				}
-
+			if !found {
 				go helper.CreateVariable(workspaceID, newVariable, &wg)
 				// When variable already exists, proceed to update the variable
 			} else {
 				// originalVariable keeps the value and description given to a variable but changes the hcl and encryption type
 				originalVariable := helper.NewVariable{
 					ID:          variable.ID,
-					Key:         newVariableName,
+					Key:         newVariable.Key,
 					Value:       variable.Value,
 					Description: variable.Description,
-					Category:    category,
-					HCL:         hcl,
-					Sensitive:   sensitive,
+					Category:    newVariable.Category,
+					HCL:         newVariable.HCL,
+					Sensitive:   newVariable.Sensitive,
 				}
 
 				// newVariable stores the value given in the command
-				newVariable := helper.NewVariable{
-					ID:          variable.ID,
-					Key:         newVariableName,
-					Value:       newVariableValue,
-					Description: variableDescription,
-					Category:    category,
-					HCL:         hcl,
-					Sensitive:   sensitive,
-				}
+				newVariable.ID = variable.ID
 
 				// If -r flag is set, proceed to recreate the variable
 				if shouldReplace {
+					variableToWrite := newVariable
 					if keepValue {
-						go helper.RecreateVariable(workspaceID, originalVariable, &wg)
-					} else {
-						go helper.RecreateVariable(workspaceID, newVariable, &wg)
+						variableToWrite = originalVariable
 					}
+					// A sensitive variable recreated without a value would lose its stored value
+					if err := helper.CheckRecreateValue(variable, variableToWrite); err != nil {
+						fmt.Println(err)
+						failed = true
+						wg.Done()
+						continue
+					}
+					go helper.RecreateVariable(workspaceID, variableToWrite, &wg)
 
 				} else {
 					/* If change from sensitive to non-sensitive or from terraform to env and vice versa,
-					print out message to use -r */
-					if variable.Category != category || variable.Sensitive != sensitive {
-						sampleCommand1 := fmt.Sprintf("tfc-help update --var %s -k -r -w %s -o %s", newVariableName, workspaceName, organizationName)
+					print out message to use -r. Marking a variable as sensitive can be done in place */
+					if variable.Category != newVariable.Category || (variable.Sensitive && !newVariable.Sensitive) {
+						sampleCommand1 := fmt.Sprintf("tfc-help update --var %s -k -r -w %s -o %s", newVariable.Key, workspaceName, organizationName)
 						fmt.Println(`One of the variables cannot be updated. Please use -r flag to recreate the variable.
 Changing from one type to another or marking a variable from sensitive to non-sensitive requires variable recreation.
 Also, if you want to keep the existing variable value and description, please use -k flag
@@ -172,10 +175,173 @@ Example commands:
 			}
 		}
 		wg.Wait()
+		if failed {
+			os.Exit(1)
+		}
 	},
 }
 
+// updateWorkspaces sends the variables to every selected workspace. Every workspace is checked and
+// planned first, then the changes are confirmed and applied workspace by workspace
+func updateWorkspaces(cmd *cobra.Command, workspaces []*tfe.Workspace, variables []helper.NewVariable, schema *helper.Schema, shouldReplace bool, keepValue bool) {
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+	plans := make([]workspaceChanges, 0, len(workspaces))
+	violations := make([]string, 0)
+	for _, result := range helper.ListVariablesInWorkspaces(workspaces, concurrency) {
+		if result.Err != nil {
+			fmt.Printf("Cannot list the variables of workspace %s: %s\n", result.Workspace.Name, result.Err)
+			os.Exit(1)
+		}
+
+		existingKeys := make([]string, 0, len(result.Variables))
+		existingVariables := make(map[string]*tfe.Variable)
+		for _, variable := range result.Variables {
+			existingKeys = append(existingKeys, variable.Key)
+			existingVariables[variable.Key] = variable
+		}
+
+		wantedVariables := make([]helper.NewVariable, 0, len(variables))
+		for _, variable := range variables {
+			// -k keeps the value and the description of the existing variable
+			if existing, found := existingVariables[variable.Key]; keepValue && found {
+				variable.Value = existing.Value
+				variable.Description = existing.Description
+			}
+			wantedVariables = append(wantedVariables, variable)
+		}
+
+		if schema != nil {
+			for _, violation := range schema.Validate(wantedVariables, existingKeys) {
+				violations = append(violations, fmt.Sprintf("%s: %s", result.Workspace.Name, violation))
+			}
+		}
+
+		changes := helper.PlanChanges(result.Variables, wantedVariables, false)
+		for i, change := range changes {
+			if change.Action == helper.ActionRecreate && !shouldReplace {
+				changes[i].Action = helper.ActionSkip
+				changes[i].Reason = "needs -r to change the category or to make it non-sensitive"
+			}
+		}
+		plans = append(plans, workspaceChanges{Workspace: result.Workspace, Changes: changes})
+	}
+	exitOnViolations(violations)
+
+	if !confirmWorkspaceChanges(cmd, plans) {
+		// The changes were not confirmed
+		if countChanges(plans) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	if failed := applyToWorkspaces(plans); failed > 0 {
+		fmt.Printf("\n%d change(s) failed\n", failed)
+		os.Exit(1)
+	}
+}
+
+// getVariableDefaults gets the description, category and flags given to the variables with the command line
+func getVariableDefaults(cmd *cobra.Command) helper.NewVariable {
+	variableDescription, _ := cmd.Flags().GetString("description")
+	isTVar, _ := cmd.Flags().GetBool("terraform")
+	hcl, _ := cmd.Flags().GetBool("hcl")
+	sensitive, _ := cmd.Flags().GetBool("sensitive")
+
+	category := tfe.CategoryEnv
+	if isTVar {
+		category = tfe.CategoryTerraform
+	}
+	return helper.NewVariable{
+		Description: variableDescription,
+		Category:    category,
+		HCL:         hcl,
+		Sensitive:   sensitive,
+	}
+}
+
+// resolveCommandValues gets the variables from the command line with the values their references point to
+func resolveCommandValues(keyPairs []string) map[string]string {
+	values, err := helper.ResolveCommandValues(keyPairs)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return values
+}
+
+// getVariablesToSend gets the variables from the command line, the environment and the variable files.
+// When the same key is given more than once, the variable files win over the command line
+func getVariablesToSend(cmd *cobra.Command, defaults helper.NewVariable) []helper.NewVariable {
+	keyPairs, _ := cmd.Flags().GetStringSlice("var")
+	env, _ := cmd.Flags().GetBool("env")
+	isTVar, _ := cmd.Flags().GetBool("terraform")
+	varFiles, _ := cmd.Flags().GetStringSlice("var-file")
+
+	var valueToSend map[string]string
+
+	if env && len(keyPairs) > 0 {
+		valueToSend = helper.GetTFValues(isTVar)
+		commandValues := resolveCommandValues(keyPairs)
+		for key := range valueToSend {
+			for commandKey, commandValue := range commandValues {
+				// Check if there are duplicated key, values in environment win
+				if key == commandKey {
+					continue
+				} else {
+					valueToSend[commandKey] = commandValue
+				}
+			}
+
+		}
+		// If only flag -e is set, then grab all variables in the current environment
+	} else if env {
+		valueToSend = helper.GetTFValues(isTVar)
+		// If only flag -v is set, then grab all variables in the command line
+	} else {
+		valueToSend = resolveCommandValues(keyPairs)
+	}
+
+	positions := make(map[string]int)
+	variables := make([]helper.NewVariable, 0, len(valueToSend))
+	addVariable := func(variable helper.NewVariable) {
+		if position, found := positions[variable.Key]; found {
+			variables[position] = variable
+			return
+		}
+		positions[variable.Key] = len(variables)
+		variables = append(variables, variable)
+	}
+
+	// Values read from a secret store are always sensitive
+	sensitiveKeys := helper.GetSensitiveCommandKeys(keyPairs)
+	for key, value := range valueToSend {
+		variable := defaults
+		variable.Key = key
+		variable.Value = value
+		variable.Sensitive = variable.Sensitive || sensitiveKeys[key]
+		addVariable(variable)
+	}
+
+	for _, varFile := range varFiles {
+		fileVariables, err := helper.LoadVariableFile(varFile, defaults)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		for _, variable := range fileVariables {
+			addVariable(variable)
+		}
+	}
+	return variables
+}
+
 func init() {
 	rootCmd.AddCommand(updateCmd)
 	updateCmd.PersistentFlags().BoolP("replace", "r", false, "Specify whether to replace the existing variable or not")
+	updateCmd.PersistentFlags().StringSlice("var-file", []string{}, `Specify a tfvars, dotenv (.env) or manifest (.yaml/.json) file with the variables to send.
+Files encrypted with age (.age) are decrypted in memory and their variables are always sensitive.
+This flag can be set multiple times`)
+	addWorkspaceSelectorFlags(updateCmd, true)
+	updateCmd.PersistentFlags().String("schema", "", "Specify a schema file the variables are checked against before any change")
 }
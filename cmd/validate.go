@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Command to check variables against a schema without sending them",
+	Long: `Command used to check the variables given with --var, --env and --var-file against a schema file.
+Every violation is listed and the command exits with an error when there is any. Terraform Cloud is not called.
+
+A schema file lists the rules of each key, every rule is optional:
+variables:
+  - key: instance_count
+    type: number        # string, number, bool, list or map
+    required: true
+  - key: region
+    allowed: [us-east-1, us-west-2]
+  - key: name
+    pattern: "[a-z-]+"  # the whole value has to match
+  - key: DB_PASSWORD
+    category: env
+    sensitive: true
+
+Examples:
+tfc-help validate --schema schema.yaml --var-file prod.tfvars
+tfc-help validate --schema schema.yaml --var instance_count=3 -t`,
+	Run: func(cmd *cobra.Command, args []string) {
+		variables, err := helper.CheckSecrets(getVariablesToSend(cmd, getVariableDefaults(cmd)))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		checkSchema(loadSchema(cmd), variables, nil)
+		fmt.Printf("%d variable(s) follow the schema\n", len(variables))
+	},
+}
+
+// loadSchema reads the --schema file. It returns nil when the flag is not set
+func loadSchema(cmd *cobra.Command) *helper.Schema {
+	schemaFile, _ := cmd.Flags().GetString("schema")
+	if schemaFile == "" {
+		return nil
+	}
+
+	schema, err := helper.LoadSchema(schemaFile)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return &schema
+}
+
+// checkSchema checks the variables against the schema read by loadSchema when there is one.
+// Every violation is printed and the command exits when there is any
+func checkSchema(schema *helper.Schema, variables []helper.NewVariable, existingKeys []string) {
+	if schema == nil {
+		return
+	}
+
+	violations := make([]string, 0)
+	for _, violation := range schema.Validate(variables, existingKeys) {
+		violations = append(violations, violation.String())
+	}
+	exitOnViolations(violations)
+}
+
+// exitOnViolations prints the violations of the schema and exits when there is any
+func exitOnViolations(violations []string) {
+	if len(violations) == 0 {
+		return
+	}
+	fmt.Printf("%d violation(s) of the schema, nothing was changed:\n", len(violations))
+	for _, violation := range violations {
+		fmt.Printf("- %s\n", violation)
+	}
+	os.Exit(1)
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().String("schema", "", "Specify the schema file the variables are checked against")
+	_ = validateCmd.MarkFlagRequired("schema")
+	validateCmd.Flags().StringSlice("var-file", []string{}, "Specify a variable file to check, in any format of update --var-file")
+}
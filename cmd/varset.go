@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/spf13/cobra"
+)
+
+// varsetCmd represents the varset command
+var varsetCmd = &cobra.Command{
+	Use:   "varset",
+	Short: "Command to manage the variable sets of an organization",
+	Long: `Command used to create and list variable sets, to manage their variables and to attach them
+to workspaces or make them global. The variables take the same flags as the update command.
+
+For more information, please use:
+tfc-helper varset [list/create/update/delete/attach/detach/copy] -h`,
+}
+
+// getVariableSet finds the variable set given with --set in the organization given with -o or the environment variable
+func getVariableSet(cmd *cobra.Command) helper.VariableSet {
+	setName, _ := cmd.Flags().GetString("set")
+	if setName == "" {
+		fmt.Println("Please set the name of the variable set with the --set flag")
+		os.Exit(1)
+	}
+
+	_, orgName := getWorkspaceAndOrganization(cmd)
+	set, err := helper.FindVariableSet(orgName, setName)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return set
+}
+
+// listVariableSetVariables lists the variables of a variable set and exits when they cannot be listed
+func listVariableSetVariables(set helper.VariableSet) []*tfe.Variable {
+	variables, err := helper.ListVariableSetVariables(set)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return variables
+}
+
+// applyToVariableSet applies the changes to a variable set one by one and prints the result of each of them.
+// It returns the number of changes that failed
+func applyToVariableSet(set helper.VariableSet, changes []helper.Change) int {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "KEY\tCATEGORY\tACTION\tRESULT")
+	failed := 0
+	for _, change := range changes {
+		result := "done"
+		if change.Action == helper.ActionSkip {
+			result = change.Reason
+		} else if err := helper.ApplyVariableSetChange(set, change); err != nil {
+			result = fmt.Sprintf("failed: %s", err)
+			failed++
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", change.Variable.Key, change.Variable.Category, change.Action, result)
+	}
+	writer.Flush()
+	return failed
+}
+
+func init() {
+	rootCmd.AddCommand(varsetCmd)
+	varsetCmd.PersistentFlags().String("set", "", "Specify the name of the variable set")
+}
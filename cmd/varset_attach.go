@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"tfc-helper/helper"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/spf13/cobra"
+)
+
+// varsetAttachCmd represents the varset attach command
+var varsetAttachCmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Command to attach a variable set to workspaces or to make it global",
+	Long: `Command used to attach a variable set to the workspace given with -w, or to every workspace
+selected with --ws-match, --ws-tag or --ws-file. Use --global to make every workspace of the organization use the set.
+
+Examples:
+tfc-help varset attach --set aws-credentials -w ws-K33Rp -o big-corp
+tfc-help varset attach --set aws-credentials --ws-tag team:payments -o big-corp
+tfc-help varset attach --set defaults --global -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		changeVariableSetWorkspaces(cmd, true)
+	},
+}
+
+// varsetDetachCmd represents the varset detach command
+var varsetDetachCmd = &cobra.Command{
+	Use:   "detach",
+	Short: "Command to detach a variable set from workspaces or to make it not global",
+	Long: `Command used to detach a variable set from the workspace given with -w, or from every workspace
+selected with --ws-match, --ws-tag or --ws-file. Use --global to stop every workspace of the organization from using the set.
+
+Examples:
+tfc-help varset detach --set aws-credentials -w ws-K33Rp -o big-corp
+tfc-help varset detach --set defaults --global -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		changeVariableSetWorkspaces(cmd, false)
+	},
+}
+
+// changeVariableSetWorkspaces attaches the variable set to the workspaces, or detaches it from them
+func changeVariableSetWorkspaces(cmd *cobra.Command, attach bool) {
+	global, _ := cmd.Flags().GetBool("global")
+	wsName, orgName := getWorkspaceAndOrganization(cmd)
+	set := getVariableSet(cmd)
+
+	if global {
+		if err := helper.SetVariableSetGlobal(set, attach); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if attach {
+			fmt.Printf("The variable set %s is now used by every workspace of organization %s\n", set.Name, orgName)
+		} else {
+			fmt.Printf("The variable set %s is not global anymore\n", set.Name)
+		}
+		return
+	}
+
+	workspaces := selectWorkspaces(cmd, orgName)
+	if workspaces == nil {
+		workspaces = []*tfe.Workspace{{ID: helper.GetWorkspaceID(orgName, wsName), Name: wsName}}
+	} else if !confirm(cmd, fmt.Sprintf("Change the variable set %s in %d workspace(s)?", set.Name, len(workspaces))) {
+		fmt.Println("Nothing was changed")
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, workspace := range workspaces {
+		var err error
+		if attach {
+			err = helper.AttachVariableSet(set, workspace)
+		} else {
+			err = helper.DetachVariableSet(set, workspace)
+		}
+		switch {
+		case err != nil:
+			fmt.Println(err)
+			failed++
+		case attach:
+			fmt.Printf("Attached the variable set %s to workspace %s\n", set.Name, workspace.Name)
+		default:
+			fmt.Printf("Detached the variable set %s from workspace %s\n", set.Name, workspace.Name)
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	for _, command := range []*cobra.Command{varsetAttachCmd, varsetDetachCmd} {
+		varsetCmd.AddCommand(command)
+		command.Flags().Bool("global", false, "Specify whether to change the variable set for every workspace of the organization")
+		addWorkspaceSelectorFlags(command, true)
+	}
+}
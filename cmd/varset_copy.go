@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"tfc-helper/helper"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/spf13/cobra"
+)
+
+// varsetCopyCmd represents the varset copy command
+var varsetCopyCmd = &cobra.Command{
+	Use:   "copy",
+	Short: "Command to copy the variables of a workspace into a new variable set",
+	Long: `Command used to create a variable set with all the variables of a workspace.
+The values of sensitive variables cannot be read, so they are only copied when their values are supplied
+with --values-file or --value. The other sensitive variables are listed at the end.
+
+Examples:
+tfc-help varset copy --src-ws template --set shared -o big-corp
+tfc-help varset copy --src-ws template --set shared --values-file secrets.env.age --attach -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		srcWsName, _ := cmd.Flags().GetString("src-ws")
+		setName, _ := cmd.Flags().GetString("set")
+		setDescription, _ := cmd.Flags().GetString("set-description")
+		global, _ := cmd.Flags().GetBool("global")
+		attach, _ := cmd.Flags().GetBool("attach")
+		wsName, orgName := getWorkspaceAndOrganization(cmd)
+		if srcWsName == "" {
+			srcWsName = wsName
+		}
+		if setName == "" {
+			fmt.Println("Please set the name of the variable set with the --set flag")
+			os.Exit(1)
+		}
+
+		srcWorkspaceID := helper.GetWorkspaceID(orgName, srcWsName)
+		variables := getSourceVariables(srcWorkspaceID)
+
+		valuesFiles, _ := cmd.Flags().GetStringSlice("values-file")
+		valuePairs, _ := cmd.Flags().GetStringSlice("value")
+		values, err := helper.LoadValues(valuesFiles, valuePairs)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		variables, missingValues := helper.FillSensitiveValues(variables, values)
+
+		variables, err = helper.CheckSecrets(variables)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		set, err := helper.CreateVariableSet(orgName, setName, setDescription, global)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Created the variable set %s (%s)\n", set.Name, set.ID)
+
+		failed := applyToVariableSet(set, helper.PlanChanges(nil, variables, false))
+		if attach {
+			if err := helper.AttachVariableSet(set, &tfe.Workspace{ID: srcWorkspaceID, Name: srcWsName}); err != nil {
+				fmt.Println(err)
+				failed++
+			} else {
+				fmt.Printf("Attached the variable set %s to workspace %s\n", set.Name, srcWsName)
+			}
+		}
+
+		if len(missingValues) > 0 {
+			fmt.Printf(`These sensitive variables were not copied because their values cannot be read: %s
+Please supply their values with --values-file or --value
+`, strings.Join(missingValues, ", "))
+			os.Exit(1)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	varsetCmd.AddCommand(varsetCopyCmd)
+	varsetCopyCmd.Flags().String("src-ws", "", "Specify the workspace to copy the variables from (default is the -w flag or TF_CLOUD_WS_NAME)")
+	varsetCopyCmd.Flags().String("set-description", "", "Specify the description of the variable set")
+	varsetCopyCmd.Flags().Bool("global", false, "Specify whether every workspace of the organization uses the variable set")
+	varsetCopyCmd.Flags().Bool("attach", false, "Specify whether to attach the variable set to the source workspace")
+	addSensitiveValueFlags(varsetCopyCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// varsetCreateCmd represents the varset create command
+var varsetCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Command to create a variable set",
+	Long: `Command used to create a variable set, optionally global and with variables given the same way
+as the update command.
+
+Examples:
+tfc-help varset create --set aws-credentials --set-description "Shared AWS account" -o big-corp
+tfc-help varset create --set defaults --global --var AWS_DEFAULT_REGION=us-east-1 -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setName, _ := cmd.Flags().GetString("set")
+		setDescription, _ := cmd.Flags().GetString("set-description")
+		global, _ := cmd.Flags().GetBool("global")
+		_, orgName := getWorkspaceAndOrganization(cmd)
+		if setName == "" {
+			fmt.Println("Please set the name of the variable set with the --set flag")
+			os.Exit(1)
+		}
+
+		variables, err := helper.CheckSecrets(getVariablesToSend(cmd, getVariableDefaults(cmd)))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		set, err := helper.CreateVariableSet(orgName, setName, setDescription, global)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Created the variable set %s (%s)\n", set.Name, set.ID)
+
+		if len(variables) > 0 {
+			if failed := applyToVariableSet(set, helper.PlanChanges(nil, variables, false)); failed > 0 {
+				fmt.Printf("\n%d variable(s) could not be added to the variable set %s\n", failed, set.Name)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	varsetCmd.AddCommand(varsetCreateCmd)
+	varsetCreateCmd.Flags().String("set-description", "", "Specify the description of the variable set")
+	varsetCreateCmd.Flags().Bool("global", false, "Specify whether every workspace of the organization uses the variable set")
+	varsetCreateCmd.Flags().StringSlice("var-file", []string{}, "Specify a variable file with the variables of the set, in any format of update --var-file")
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// varsetDeleteCmd represents the varset delete command
+var varsetDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Command to delete variables in a variable set",
+	Long: `Command used to delete variable(s) of a variable set. Use -a flag to delete all variables of the set.
+
+Examples:
+tfc-help varset delete --set aws-credentials --var AWS_SESSION_TOKEN -o big-corp
+tfc-help varset delete --set aws-credentials -a -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		keyPairs, _ := cmd.Flags().GetStringSlice("var")
+		allVar, _ := cmd.Flags().GetBool("all")
+		keys := helper.GetCommandValues(keyPairs)
+
+		set := getVariableSet(cmd)
+		changes := make([]helper.Change, 0)
+		found := make(map[string]bool)
+		for _, variable := range listVariableSetVariables(set) {
+			if _, selected := keys[variable.Key]; allVar || selected {
+				found[variable.Key] = true
+				changes = append(changes, helper.Change{
+					Action:   helper.ActionDelete,
+					Variable: helper.NewVariable{ID: variable.ID, Key: variable.Key, Category: variable.Category},
+					Existing: variable,
+				})
+			}
+		}
+		for key := range keys {
+			if !found[key] {
+				changes = append(changes, helper.Change{Action: helper.ActionSkip, Variable: helper.NewVariable{Key: key}, Reason: "does not exist"})
+			}
+		}
+		if len(changes) == 0 {
+			fmt.Printf("No variable to delete in the variable set %s\n", set.Name)
+			return
+		}
+
+		if failed := applyToVariableSet(set, changes); failed > 0 {
+			fmt.Printf("\n%d change(s) failed\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	varsetCmd.AddCommand(varsetDeleteCmd)
+	varsetDeleteCmd.Flags().BoolP("all", "a", false, "Specify whether to delete all variables of the set")
+}
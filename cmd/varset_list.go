@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// varsetListCmd represents the varset list command
+var varsetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Command to list the variable sets of an organization or the variables of a set",
+	Long: `Command used to list the variable sets of an organization with the number of workspaces
+they are attached to. With --set, the variables of the set are listed instead.
+The values of sensitive variables are never shown.
+
+Examples:
+tfc-help varset list -o big-corp
+tfc-help varset list --set aws-credentials -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		defer writer.Flush()
+
+		if setName, _ := cmd.Flags().GetString("set"); setName != "" {
+			fmt.Fprintln(writer, "KEY\tCATEGORY\tHCL\tSENSITIVE\tVALUE")
+			for _, variable := range listVariableSetVariables(getVariableSet(cmd)) {
+				value := variable.Value
+				if variable.Sensitive {
+					value = "(sensitive)"
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%t\t%t\t%s\n", variable.Key, variable.Category, variable.HCL, variable.Sensitive, value)
+			}
+			return
+		}
+
+		_, orgName := getWorkspaceAndOrganization(cmd)
+		sets, err := helper.ListVariableSets(orgName)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(writer, "NAME\tID\tGLOBAL\tWORKSPACES\tDESCRIPTION")
+		for _, set := range sets {
+			workspaces := fmt.Sprint(len(set.WorkspaceIDs))
+			if set.Global {
+				workspaces = "all"
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%t\t%s\t%s\n", set.Name, set.ID, set.Global, workspaces, set.Description)
+		}
+	},
+}
+
+func init() {
+	varsetCmd.AddCommand(varsetListCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"tfc-helper/helper"
+
+	"github.com/spf13/cobra"
+)
+
+// varsetUpdateCmd represents the varset update command
+var varsetUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Command to create/update variables in a variable set",
+	Long: `Command used to create the variables that do not exist in a variable set and to update the ones that do.
+The variables take the same flags as the update command. Changing the category of a variable or making
+it non-sensitive requires the -r flag to recreate it, and -k keeps the existing value and description.
+
+Examples:
+tfc-help varset update --set aws-credentials --var AWS_ACCESS_KEY_ID=env:AWS_ACCESS_KEY_ID -s -o big-corp
+tfc-help varset update --set defaults --var-file defaults.tfvars -o big-corp`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shouldReplace, _ := cmd.Flags().GetBool("replace")
+		keepValue, _ := cmd.Flags().GetBool("keep")
+
+		variables, err := helper.CheckSecrets(getVariablesToSend(cmd, getVariableDefaults(cmd)))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		set := getVariableSet(cmd)
+		existingVariables := listVariableSetVariables(set)
+		if keepValue {
+			for i, variable := range variables {
+				for _, existing := range existingVariables {
+					if existing.Key == variable.Key {
+						variables[i].Value = existing.Value
+						variables[i].Description = existing.Description
+					}
+				}
+			}
+		}
+
+		changes := helper.PlanChanges(existingVariables, variables, false)
+		for i, change := range changes {
+			if change.Action == helper.ActionRecreate && !shouldReplace {
+				changes[i].Action = helper.ActionSkip
+				changes[i].Reason = "needs -r to change the category or to make it non-sensitive"
+			}
+		}
+		if len(changes) == 0 {
+			fmt.Printf("The variable set %s is already up to date\n", set.Name)
+			return
+		}
+
+		if failed := applyToVariableSet(set, changes); failed > 0 {
+			fmt.Printf("\n%d change(s) failed\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	varsetCmd.AddCommand(varsetUpdateCmd)
+	varsetUpdateCmd.Flags().BoolP("replace", "r", false, "Specify whether to replace the existing variable or not")
+	varsetUpdateCmd.Flags().StringSlice("var-file", []string{}, "Specify a variable file with the variables to send, in any format of update --var-file")
+}
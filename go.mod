@@ -3,9 +3,14 @@ module tfc-helper
 go 1.15
 
 require (
+	filippo.io/age v1.0.0
+	github.com/hashicorp/go-cleanhttp v0.5.2
 	github.com/hashicorp/go-tfe v0.11.1
 	github.com/hashicorp/hcl/v2 v2.8.0
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/spf13/cobra v1.1.1
 	github.com/spf13/viper v1.7.1
+	github.com/zclconf/go-cty v1.2.0
+	go.mozilla.org/sops/v3 v3.7.3
+	gopkg.in/yaml.v2 v2.4.0
 )
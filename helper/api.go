@@ -0,0 +1,150 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// apiDocument is a JSON:API document of the Terraform Cloud API
+type apiDocument struct {
+	Data     json.RawMessage `json:"data"`
+	Included []apiResource   `json:"included,omitempty"`
+	Meta     *apiMeta        `json:"meta,omitempty"`
+}
+
+// apiResource is a single JSON:API resource
+type apiResource struct {
+	ID            string                     `json:"id,omitempty"`
+	Type          string                     `json:"type"`
+	Attributes    map[string]interface{}     `json:"attributes,omitempty"`
+	Relationships map[string]apiRelationship `json:"relationships,omitempty"`
+}
+
+// apiRelationship is a JSON:API relationship to one or many resources
+type apiRelationship struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// apiMeta has the pagination of a list
+type apiMeta struct {
+	Pagination struct {
+		CurrentPage int `json:"current-page"`
+		NextPage    int `json:"next-page"`
+	} `json:"pagination"`
+}
+
+// apiErrors is the error document returned by the Terraform Cloud API
+type apiErrors struct {
+	Errors []struct {
+		Status string `json:"status"`
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+// apiRequest sends a request to the Terraform Cloud API for what go-tfe does not support yet.
+// path is relative to /api/v2/, body is sent as the data of a JSON:API document and the response
+// document is returned. A nil document is returned for responses without content
+func apiRequest(method string, path string, query url.Values, body interface{}) (*apiDocument, error) {
+	// The client keeps the token and the HTTP client with the transport settings
+	getClient()
+
+	address, err := url.Parse(strings.TrimSuffix(tfe.DefaultConfig().Address, "/") + tfe.DefaultBasePath + path)
+	if err != nil {
+		return nil, err
+	}
+	address.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(map[string]interface{}{"data": body})
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	request, err := http.NewRequest(method, address.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Authorization", "Bearer "+apiToken)
+	request.Header.Set("Accept", "application/vnd.api+json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/vnd.api+json")
+	}
+
+	response, err := apiHTTPClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= 300 {
+		var errorDocument apiErrors
+		if json.Unmarshal(content, &errorDocument) == nil && len(errorDocument.Errors) > 0 {
+			messages := make([]string, 0, len(errorDocument.Errors))
+			for _, apiError := range errorDocument.Errors {
+				message := apiError.Title
+				if apiError.Detail != "" {
+					message = apiError.Detail
+				}
+				messages = append(messages, message)
+			}
+			return nil, fmt.Errorf("%s %s failed: %s", method, path, strings.Join(messages, ", "))
+		}
+		return nil, fmt.Errorf("%s %s failed: %s", method, path, response.Status)
+	}
+
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, nil
+	}
+	var document apiDocument
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse the response of %s %s: %s", method, path, err)
+	}
+	return &document, nil
+}
+
+// apiList sends a GET request to a list and goes through every page of it
+func apiList(path string, query url.Values) ([]apiResource, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("page[size]", "100")
+
+	resources := make([]apiResource, 0)
+	page := 1
+	for {
+		query.Set("page[number]", fmt.Sprint(page))
+		document, err := apiRequest("GET", path, query, nil)
+		if err != nil {
+			return nil, err
+		}
+		if document != nil {
+			var pageResources []apiResource
+			if err := json.Unmarshal(document.Data, &pageResources); err != nil {
+				return nil, fmt.Errorf("failed to parse the response of GET %s: %s", path, err)
+			}
+			resources = append(resources, pageResources...)
+		}
+
+		if document == nil || document.Meta == nil || document.Meta.Pagination.NextPage == 0 || document.Meta.Pagination.NextPage == page {
+			return resources, nil
+		}
+		page = document.Meta.Pagination.NextPage
+	}
+}
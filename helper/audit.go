@@ -0,0 +1,180 @@
+package helper
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// AuditRecord is a single line of the audit log. Values are only written as hashes, and sensitive values not at all
+type AuditRecord struct {
+	Timestamp    string `json:"timestamp"`
+	Operator     string `json:"operator"`
+	Host         string `json:"host"`
+	Organization string `json:"organization"`
+	Workspace    string `json:"workspace,omitempty"`
+	VariableSet  string `json:"variable_set,omitempty"`
+	Key          string `json:"key"`
+	Action       string `json:"action"`
+	Sensitive    bool   `json:"sensitive"`
+	OldValueHash string `json:"old_value_hash,omitempty"`
+	NewValueHash string `json:"new_value_hash,omitempty"`
+}
+
+// workspaceNames has the organization and workspace names for a workspace ID
+type workspaceNames struct {
+	organization string
+	workspace    string
+}
+
+var auditLog io.Writer
+var auditMutex sync.Mutex
+var auditOperator string
+var auditOperatorOnce sync.Once
+var auditWorkspaces = make(map[string]workspaceNames)
+
+// SetAuditLog sets the file the audit records are appended to. "-" writes the records to stdout
+// and an empty path disables the audit log
+func SetAuditLog(path string) error {
+	switch path {
+	case "":
+		auditLog = nil
+	case "-":
+		auditLog = os.Stdout
+	default:
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open audit log: %s", err)
+		}
+		auditLog = file
+	}
+	return nil
+}
+
+// readVariableForAudit reads the current state of a variable before it gets changed.
+// It returns nil when the audit log is disabled or the variable cannot be read
+func readVariableForAudit(workspaceID string, variableID string) *tfe.Variable {
+	if auditLog == nil || variableID == "" {
+		return nil
+	}
+	variable, err := getClient().Variables.Read(ctx, workspaceID, variableID)
+	if err != nil {
+		return nil
+	}
+	return variable
+}
+
+// writeAuditRecord appends a record for a mutation to the audit log when it is enabled
+func writeAuditRecord(workspaceID string, action string, oldVariable *tfe.Variable, newVariable *NewVariable) {
+	if auditLog == nil {
+		return
+	}
+
+	names := auditWorkspaceNames(workspaceID)
+	appendAuditRecord(AuditRecord{
+		Organization: names.organization,
+		Workspace:    names.workspace,
+		Action:       action,
+	}, oldVariable, newVariable)
+}
+
+// writeVariableSetAuditRecord appends a record for a mutation of a variable set to the audit log when it is enabled.
+// workspaceName is only set when a workspace is attached to the set or detached from it
+func writeVariableSetAuditRecord(set VariableSet, workspaceName string, action string, oldVariable *tfe.Variable, newVariable *NewVariable) {
+	if auditLog == nil {
+		return
+	}
+
+	appendAuditRecord(AuditRecord{
+		Organization: set.Organization,
+		Workspace:    workspaceName,
+		VariableSet:  set.Name,
+		Action:       action,
+	}, oldVariable, newVariable)
+}
+
+// appendAuditRecord completes a record with the operator, the host and the variable, then appends it to the audit log
+func appendAuditRecord(record AuditRecord, oldVariable *tfe.Variable, newVariable *NewVariable) {
+	record.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	record.Operator = auditOperatorName()
+	record.Host = hostname()
+
+	if oldVariable != nil {
+		record.Key = oldVariable.Key
+		record.Sensitive = oldVariable.Sensitive
+		// The API never returns the value of a sensitive variable
+		if !oldVariable.Sensitive {
+			record.OldValueHash = hashValue(oldVariable.Value)
+		}
+	}
+	if newVariable != nil {
+		record.Key = newVariable.Key
+		record.Sensitive = newVariable.Sensitive
+		// A hash of a sensitive value could be brute-forced from the log, so only plain values are hashed
+		if !newVariable.Sensitive {
+			record.NewValueHash = hashValue(newVariable.Value)
+		} else {
+			// A plain value made sensitive is usually the secret itself
+			record.OldValueHash = ""
+		}
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		fmt.Println("Failed to write audit record:", err)
+		return
+	}
+
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+	if _, err := auditLog.Write(append(line, '\n')); err != nil {
+		fmt.Println("Failed to write audit record:", err)
+	}
+}
+
+// auditOperatorName gets the name of the user or service account the token belongs to
+func auditOperatorName() string {
+	auditOperatorOnce.Do(func() {
+		auditOperator = "unknown"
+		user, err := getClient().Users.ReadCurrent(ctx)
+		if err == nil {
+			auditOperator = user.Username
+		}
+	})
+	return auditOperator
+}
+
+// auditWorkspaceNames gets the organization and workspace names of a workspace ID
+func auditWorkspaceNames(workspaceID string) workspaceNames {
+	auditMutex.Lock()
+	names, found := auditWorkspaces[workspaceID]
+	auditMutex.Unlock()
+	if found {
+		return names
+	}
+
+	names = workspaceNames{workspace: workspaceID}
+	workspace, err := getClient().Workspaces.ReadByID(ctx, workspaceID)
+	if err == nil {
+		names.workspace = workspace.Name
+		if workspace.Organization != nil {
+			names.organization = workspace.Organization.Name
+		}
+	}
+
+	auditMutex.Lock()
+	auditWorkspaces[workspaceID] = names
+	auditMutex.Unlock()
+	return names
+}
+
+// hashValue hashes a value so the audit log can show a change without showing the value
+func hashValue(value string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(value)))
+}
@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestAppendAuditRecord(t *testing.T) {
+	var out bytes.Buffer
+	auditLog = &out
+	defer func() { auditLog = nil }()
+	// The operator is read from the API once, set it so no client is created
+	auditOperatorOnce.Do(func() { auditOperator = "tester" })
+
+	tests := []struct {
+		name        string
+		oldVariable *tfe.Variable
+		newVariable *NewVariable
+		wantOldHash bool
+		wantNewHash bool
+	}{
+		{
+			name:        "plain update",
+			oldVariable: &tfe.Variable{Key: "REGION", Value: "us-east-1"},
+			newVariable: &NewVariable{Key: "REGION", Value: "eu-west-1"},
+			wantOldHash: true,
+			wantNewHash: true,
+		},
+		{
+			name:        "sensitive create",
+			newVariable: &NewVariable{Key: "DB_PASSWORD", Value: "hunter2", Sensitive: true},
+		},
+		{
+			name:        "made sensitive",
+			oldVariable: &tfe.Variable{Key: "DB_PASSWORD", Value: "hunter2"},
+			newVariable: &NewVariable{Key: "DB_PASSWORD", Value: "hunter2", Sensitive: true},
+		},
+		{
+			name:        "sensitive delete",
+			oldVariable: &tfe.Variable{Key: "DB_PASSWORD", Sensitive: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out.Reset()
+			appendAuditRecord(AuditRecord{Organization: "org", Workspace: "ws", Action: "update"}, test.oldVariable, test.newVariable)
+
+			var record AuditRecord
+			if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+				t.Fatal(err)
+			}
+			if record.Operator != "tester" || record.Workspace != "ws" {
+				t.Errorf("unexpected record %+v", record)
+			}
+			if got := record.OldValueHash != ""; got != test.wantOldHash {
+				t.Errorf("old value hashed: got %v, want %v", got, test.wantOldHash)
+			}
+			if got := record.NewValueHash != ""; got != test.wantNewHash {
+				t.Errorf("new value hashed: got %v, want %v", got, test.wantNewHash)
+			}
+			if test.newVariable != nil && test.newVariable.Sensitive && bytes.Contains(out.Bytes(), []byte(hashValue(test.newVariable.Value))) {
+				t.Error("the hash of a sensitive value was written")
+			}
+		})
+	}
+}
+
+func TestRecreateVariableEAudit(t *testing.T) {
+	var out bytes.Buffer
+	auditLog = &out
+	defer func() { auditLog = nil }()
+	auditOperatorOnce.Do(func() { auditOperator = "tester" })
+
+	tests := []struct {
+		name         string
+		createStatus int
+		wantAction   string
+	}{
+		{name: "recreated", createStatus: http.StatusCreated, wantAction: "recreate"},
+		// The variable is deleted even though it could not be created again
+		{name: "create failed", createStatus: http.StatusUnprocessableEntity, wantAction: "delete"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out.Reset()
+			newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/vars/var-1"):
+					writeTestVariable(w, http.StatusOK, "var-1", "REGION", false)
+				case r.Method == "DELETE":
+					w.WriteHeader(http.StatusNoContent)
+				case r.Method == "POST" && test.createStatus != http.StatusCreated:
+					w.WriteHeader(test.createStatus)
+				case r.Method == "POST":
+					writeTestVariable(w, test.createStatus, "var-2", "REGION", false)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+
+			err := RecreateVariableE("ws-1", NewVariable{ID: "var-1", Key: "REGION", Value: "eu-west-1", Category: tfe.CategoryTerraform})
+			if (err != nil) != (test.wantAction == "delete") {
+				t.Fatalf("got error %v", err)
+			}
+
+			var record AuditRecord
+			if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+				t.Fatalf("no audit record: %s", err)
+			}
+			if record.Action != test.wantAction || record.Key != "REGION" {
+				t.Errorf("got record %+v, want action %s", record, test.wantAction)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// AWSCredentials has the credentials and region of an AWS profile
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+}
+
+// GetAWSCredentials reads the credentials of a profile from the AWS shared credentials file and its region
+// from the AWS config file. AWS_SHARED_CREDENTIALS_FILE and AWS_CONFIG_FILE are honored like in the AWS CLI
+func GetAWSCredentials(profile string) (AWSCredentials, error) {
+	var credentials AWSCredentials
+	homeDir, _ := os.UserHomeDir()
+
+	credentialsPath := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credentialsPath == "" {
+		credentialsPath = filepath.Join(homeDir, ".aws", "credentials")
+	}
+	configPath := os.Getenv("AWS_CONFIG_FILE")
+	if configPath == "" {
+		configPath = filepath.Join(homeDir, ".aws", "config")
+	}
+
+	credentialsFile, err := readINIFile(credentialsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return credentials, err
+	}
+	configFile, err := readINIFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return credentials, err
+	}
+
+	// Profiles in the config file are named "profile <name>", except for the default profile
+	configSection := configFile["profile "+profile]
+	if profile == "default" && configSection == nil {
+		configSection = configFile["default"]
+	}
+	credentialsSection := credentialsFile[profile]
+	if credentialsSection == nil && configSection == nil {
+		return credentials, fmt.Errorf("AWS profile %s not found in %s or %s", profile, credentialsPath, configPath)
+	}
+
+	// Credentials can be in either file, the credentials file wins
+	getValue := func(key string) string {
+		if value := credentialsSection[key]; value != "" {
+			return value
+		}
+		return configSection[key]
+	}
+
+	credentials.AccessKeyID = getValue("aws_access_key_id")
+	credentials.SecretAccessKey = getValue("aws_secret_access_key")
+	credentials.SessionToken = getValue("aws_session_token")
+	credentials.Region = configSection["region"]
+	if credentials.Region == "" {
+		credentials.Region = credentialsSection["region"]
+	}
+
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return credentials, fmt.Errorf("AWS profile %s has no static access key, profiles using SSO or roles are not supported", profile)
+	}
+	return credentials, nil
+}
+
+// Variables gets the environment variables the AWS provider reads the credentials from
+func (credentials AWSCredentials) Variables() []NewVariable {
+	values := [][2]string{
+		{"AWS_ACCESS_KEY_ID", credentials.AccessKeyID},
+		{"AWS_SECRET_ACCESS_KEY", credentials.SecretAccessKey},
+		{"AWS_SESSION_TOKEN", credentials.SessionToken},
+		{"AWS_DEFAULT_REGION", credentials.Region},
+	}
+
+	variables := make([]NewVariable, 0, len(values))
+	for _, value := range values {
+		// The session token and the region are optional
+		if value[1] == "" {
+			continue
+		}
+		variables = append(variables, NewVariable{
+			Key:       value[0],
+			Value:     value[1],
+			Category:  tfe.CategoryEnv,
+			Sensitive: true,
+		})
+	}
+	return variables
+}
+
+// readINIFile reads the sections of an INI file such as the AWS credentials and config files
+func readINIFile(path string) (map[string]map[string]string, error) {
+	sections := make(map[string]map[string]string)
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return sections, err
+	}
+
+	var section map[string]string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			section = make(map[string]string)
+			sections[name] = section
+		case section != nil:
+			pair := strings.SplitN(line, "=", 2)
+			if len(pair) == 2 {
+				section[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+			}
+		}
+	}
+	return sections, scanner.Err()
+}
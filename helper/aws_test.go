@@ -0,0 +1,116 @@
+package helper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+const testAWSCredentialsFile = `# comment
+[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = default-secret
+
+[ci]
+aws_access_key_id=AKIACI
+aws_secret_access_key=ci-secret
+aws_session_token=ci-token
+region=eu-central-1
+
+[sso]
+; SSO profiles have no static key
+`
+
+const testAWSConfigFile = `[default]
+region = us-east-1
+
+[profile ci]
+region = eu-west-1
+
+[profile config-only]
+aws_access_key_id = AKIACONFIG
+aws_secret_access_key = config-secret
+
+[profile sso]
+sso_start_url = https://example.awsapps.com/start
+region = us-west-2
+`
+
+func TestReadINIFile(t *testing.T) {
+	sections, err := readINIFile(writeTestFile(t, "credentials", []byte(testAWSCredentialsFile)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]string{
+		"default": {"aws_access_key_id": "AKIADEFAULT", "aws_secret_access_key": "default-secret"},
+		"ci":      {"aws_access_key_id": "AKIACI", "aws_secret_access_key": "ci-secret", "aws_session_token": "ci-token", "region": "eu-central-1"},
+		"sso":     {},
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("got %v, want %v", sections, want)
+	}
+}
+
+func TestGetAWSCredentials(t *testing.T) {
+	setEnv(t, "AWS_SHARED_CREDENTIALS_FILE", writeTestFile(t, "credentials", []byte(testAWSCredentialsFile)))
+	setEnv(t, "AWS_CONFIG_FILE", writeTestFile(t, "config", []byte(testAWSConfigFile)))
+
+	tests := []struct {
+		profile string
+		want    AWSCredentials
+		wantErr string
+	}{
+		{
+			profile: "default",
+			want:    AWSCredentials{AccessKeyID: "AKIADEFAULT", SecretAccessKey: "default-secret", Region: "us-east-1"},
+		},
+		{
+			// The region of the config file wins over the one of the credentials file
+			profile: "ci",
+			want:    AWSCredentials{AccessKeyID: "AKIACI", SecretAccessKey: "ci-secret", SessionToken: "ci-token", Region: "eu-west-1"},
+		},
+		{
+			profile: "config-only",
+			want:    AWSCredentials{AccessKeyID: "AKIACONFIG", SecretAccessKey: "config-secret"},
+		},
+		{
+			profile: "sso",
+			wantErr: "no static access key",
+		},
+		{
+			profile: "missing",
+			wantErr: "AWS profile missing not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.profile, func(t *testing.T) {
+			got, err := GetAWSCredentials(test.profile)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want an error containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAWSCredentialsVariables(t *testing.T) {
+	got := AWSCredentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"}.Variables()
+	want := []NewVariable{
+		{Key: "AWS_ACCESS_KEY_ID", Value: "AKIA", Category: tfe.CategoryEnv, Sensitive: true},
+		{Key: "AWS_SECRET_ACCESS_KEY", Value: "secret", Category: tfe.CategoryEnv, Sensitive: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
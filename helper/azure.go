@@ -0,0 +1,157 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// AzureCredentials has the service principal credentials the Azure provider needs
+type AzureCredentials struct {
+	ClientID       string `json:"clientId"`
+	ClientSecret   string `json:"clientSecret"`
+	TenantID       string `json:"tenantId"`
+	SubscriptionID string `json:"subscriptionId"`
+}
+
+// azureProfile is the structure of the azureProfile.json file of the az CLI
+type azureProfile struct {
+	Subscriptions []struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		TenantID  string `json:"tenantId"`
+		IsDefault bool   `json:"isDefault"`
+		User      struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"user"`
+	} `json:"subscriptions"`
+}
+
+// azureServicePrincipalEntry is an entry of service_principal_entries.json (az CLI 2.30 and later)
+// or of accessTokens.json (older az CLI) where the client secret of a service principal login is kept
+type azureServicePrincipalEntry struct {
+	ClientID     string `json:"client_id"`
+	Tenant       string `json:"tenant"`
+	ClientSecret string `json:"client_secret"`
+	// Fields used by accessTokens.json
+	ServicePrincipalID     string `json:"servicePrincipalId"`
+	ServicePrincipalTenant string `json:"servicePrincipalTenant"`
+	AccessToken            string `json:"accessToken"`
+}
+
+// azureSubscriptionIDPattern matches a subscription ID, auth files have no subscription names
+var azureSubscriptionIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// GetAzureCredentialsFromAuthFile reads the credentials from an SDK auth file,
+// such as the output of "az ad sp create-for-rbac --sdk-auth".
+// subscription is the ID of the subscription to use instead of the one of the file, when it is set
+func GetAzureCredentialsFromAuthFile(path string, subscription string) (AzureCredentials, error) {
+	var credentials AzureCredentials
+	if subscription != "" && !azureSubscriptionIDPattern.MatchString(subscription) {
+		return credentials, fmt.Errorf("subscription %s is not a subscription ID, only IDs can be used with an auth file", subscription)
+	}
+	if err := readAzureJSON(path, &credentials); err != nil {
+		return credentials, err
+	}
+	if subscription != "" {
+		credentials.SubscriptionID = subscription
+	}
+	return credentials, credentials.validate(path)
+}
+
+// GetAzureCredentialsFromProfile reads the credentials of a service principal logged in with the az CLI.
+// subscription is the name or ID of the subscription, the default subscription is used when it is empty
+func GetAzureCredentialsFromProfile(configDir string, subscription string) (AzureCredentials, error) {
+	var credentials AzureCredentials
+	var profile azureProfile
+	if err := readAzureJSON(filepath.Join(configDir, "azureProfile.json"), &profile); err != nil {
+		return credentials, err
+	}
+
+	found := false
+	for _, azureSubscription := range profile.Subscriptions {
+		if (subscription == "" && azureSubscription.IsDefault) || subscription == azureSubscription.ID || subscription == azureSubscription.Name {
+			if azureSubscription.User.Type != "servicePrincipal" {
+				return credentials, fmt.Errorf("subscription %s is used by user %s, please log in with a service principal", azureSubscription.Name, azureSubscription.User.Name)
+			}
+			credentials.SubscriptionID = azureSubscription.ID
+			credentials.TenantID = azureSubscription.TenantID
+			credentials.ClientID = azureSubscription.User.Name
+			found = true
+			break
+		}
+	}
+	if !found {
+		return credentials, errors.New("subscription not found in the az CLI profile")
+	}
+
+	secret, err := getAzureClientSecret(configDir, credentials.ClientID, credentials.TenantID)
+	if err != nil {
+		return credentials, err
+	}
+	credentials.ClientSecret = secret
+	return credentials, credentials.validate(filepath.Join(configDir, "azureProfile.json"))
+}
+
+// getAzureClientSecret finds the client secret of a service principal in the az CLI files
+func getAzureClientSecret(configDir string, clientID string, tenantID string) (string, error) {
+	for _, name := range []string{"service_principal_entries.json", "accessTokens.json"} {
+		var entries []azureServicePrincipalEntry
+		if err := readAzureJSON(filepath.Join(configDir, name), &entries); err != nil {
+			if os.IsNotExist(errors.Unwrap(err)) {
+				continue
+			}
+			return "", err
+		}
+
+		for _, entry := range entries {
+			if entry.ClientID == clientID && entry.Tenant == tenantID && entry.ClientSecret != "" {
+				return entry.ClientSecret, nil
+			}
+			if entry.ServicePrincipalID == clientID && entry.ServicePrincipalTenant == tenantID && entry.AccessToken != "" {
+				return entry.AccessToken, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no client secret found for service principal %s, certificate logins are not supported", clientID)
+}
+
+// readAzureJSON reads a JSON file written by the az CLI, which can start with a byte order mark
+func readAzureJSON(path string, value interface{}) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	if err := json.Unmarshal(content, value); err != nil {
+		return fmt.Errorf("%s is not valid JSON: %s", path, err)
+	}
+	return nil
+}
+
+// validate checks that none of the credentials is missing
+func (credentials AzureCredentials) validate(path string) error {
+	if credentials.ClientID == "" || credentials.ClientSecret == "" || credentials.TenantID == "" || credentials.SubscriptionID == "" {
+		return fmt.Errorf("%s does not have the client ID, client secret, tenant ID and subscription ID", path)
+	}
+	return nil
+}
+
+// Variables gets the environment variables the Azure provider reads the credentials from.
+// Only the client secret is sensitive
+func (credentials AzureCredentials) Variables() []NewVariable {
+	return []NewVariable{
+		{Key: "ARM_CLIENT_ID", Value: credentials.ClientID, Category: tfe.CategoryEnv},
+		{Key: "ARM_CLIENT_SECRET", Value: credentials.ClientSecret, Category: tfe.CategoryEnv, Sensitive: true},
+		{Key: "ARM_TENANT_ID", Value: credentials.TenantID, Category: tfe.CategoryEnv},
+		{Key: "ARM_SUBSCRIPTION_ID", Value: credentials.SubscriptionID, Category: tfe.CategoryEnv},
+	}
+}
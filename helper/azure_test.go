@@ -0,0 +1,157 @@
+package helper
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+const (
+	testAzureSubscription      = "11111111-1111-1111-1111-111111111111"
+	testAzureOtherSubscription = "22222222-2222-2222-2222-222222222222"
+	testAzureTenant            = "33333333-3333-3333-3333-333333333333"
+	testAzureClient            = "44444444-4444-4444-4444-444444444444"
+)
+
+func TestGetAzureCredentialsFromAuthFile(t *testing.T) {
+	authFile := writeTestFile(t, "sp.json", []byte(`{
+  "clientId": "`+testAzureClient+`",
+  "clientSecret": "client-secret",
+  "subscriptionId": "`+testAzureSubscription+`",
+  "tenantId": "`+testAzureTenant+`",
+  "activeDirectoryEndpointUrl": "https://login.microsoftonline.com"
+}`))
+	incompleteFile := writeTestFile(t, "sp.json", []byte(`{"clientId": "`+testAzureClient+`"}`))
+
+	tests := []struct {
+		name         string
+		path         string
+		subscription string
+		want         AzureCredentials
+		wantErr      string
+	}{
+		{
+			name: "subscription of the file",
+			path: authFile,
+			want: AzureCredentials{ClientID: testAzureClient, ClientSecret: "client-secret", TenantID: testAzureTenant, SubscriptionID: testAzureSubscription},
+		},
+		{
+			name:         "subscription ID given",
+			path:         authFile,
+			subscription: testAzureOtherSubscription,
+			want:         AzureCredentials{ClientID: testAzureClient, ClientSecret: "client-secret", TenantID: testAzureTenant, SubscriptionID: testAzureOtherSubscription},
+		},
+		{
+			name:         "subscription name given",
+			path:         authFile,
+			subscription: "production",
+			wantErr:      "only IDs can be used",
+		},
+		{
+			name:    "incomplete file",
+			path:    incompleteFile,
+			wantErr: "does not have the client ID",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := GetAzureCredentialsFromAuthFile(test.path, test.subscription)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want an error containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetAzureCredentialsFromProfile(t *testing.T) {
+	configDir := t.TempDir()
+	files := map[string]string{
+		// The az CLI writes its files with a byte order mark
+		"azureProfile.json": "\xef\xbb\xbf" + `{"subscriptions": [
+  {"id": "` + testAzureSubscription + `", "name": "production", "tenantId": "` + testAzureTenant + `", "isDefault": true,
+   "user": {"name": "` + testAzureClient + `", "type": "servicePrincipal"}},
+  {"id": "` + testAzureOtherSubscription + `", "name": "personal", "tenantId": "` + testAzureTenant + `", "isDefault": false,
+   "user": {"name": "someone@example.com", "type": "user"}}
+]}`,
+		"service_principal_entries.json": `[{"client_id": "` + testAzureClient + `", "tenant": "` + testAzureTenant + `", "client_secret": "client-secret"}]`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(configDir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := AzureCredentials{ClientID: testAzureClient, ClientSecret: "client-secret", TenantID: testAzureTenant, SubscriptionID: testAzureSubscription}
+
+	tests := []struct {
+		subscription string
+		wantErr      string
+	}{
+		{subscription: ""},
+		{subscription: "production"},
+		{subscription: testAzureSubscription},
+		{subscription: "personal", wantErr: "please log in with a service principal"},
+		{subscription: "missing", wantErr: "subscription not found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.subscription, func(t *testing.T) {
+			got, err := GetAzureCredentialsFromProfile(configDir, test.subscription)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want an error containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestGetAzureClientSecretFromAccessTokens(t *testing.T) {
+	// Older az CLI versions keep the secret in accessTokens.json
+	configDir := t.TempDir()
+	content := `[{"servicePrincipalId": "` + testAzureClient + `", "servicePrincipalTenant": "` + testAzureTenant + `", "accessToken": "client-secret"}]`
+	if err := ioutil.WriteFile(filepath.Join(configDir, "accessTokens.json"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := getAzureClientSecret(configDir, testAzureClient, testAzureTenant)
+	if err != nil || secret != "client-secret" {
+		t.Errorf("got %q and error %v, want client-secret", secret, err)
+	}
+	if _, err := getAzureClientSecret(configDir, testAzureClient, testAzureOtherSubscription); err == nil {
+		t.Error("got no error for a service principal of another tenant")
+	}
+}
+
+func TestAzureCredentialsVariables(t *testing.T) {
+	got := AzureCredentials{ClientID: "client", ClientSecret: "secret", TenantID: "tenant", SubscriptionID: "subscription"}.Variables()
+	want := []NewVariable{
+		{Key: "ARM_CLIENT_ID", Value: "client", Category: tfe.CategoryEnv},
+		{Key: "ARM_CLIENT_SECRET", Value: "secret", Category: tfe.CategoryEnv, Sensitive: true},
+		{Key: "ARM_TENANT_ID", Value: "tenant", Category: tfe.CategoryEnv},
+		{Key: "ARM_SUBSCRIPTION_ID", Value: "subscription", Category: tfe.CategoryEnv},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/hashicorp/go-tfe"
+)
+
+// BackupVersion is the version of the backup format
+const BackupVersion = 1
+
+// Backup is a snapshot of every variable of a workspace
+type Backup struct {
+	Version      int              `json:"version"`
+	CreatedAt    time.Time        `json:"created_at"`
+	Host         string           `json:"host"`
+	Organization string           `json:"organization"`
+	Workspace    string           `json:"workspace"`
+	Variables    []BackupVariable `json:"variables"`
+}
+
+// BackupVariable is a variable with all its attributes.
+// The value of a sensitive variable is empty unless it was supplied when the backup was made
+type BackupVariable struct {
+	Key         string           `json:"key"`
+	Value       string           `json:"value"`
+	Description string           `json:"description"`
+	Category    tfe.CategoryType `json:"category"`
+	HCL         bool             `json:"hcl"`
+	Sensitive   bool             `json:"sensitive"`
+}
+
+// NewBackup takes a snapshot of the variables of a workspace.
+// values has the values of the sensitive variables, which the API never returns
+func NewBackup(orgName string, wsName string, values map[string]string) Backup {
+	backup := Backup{
+		Version:      BackupVersion,
+		CreatedAt:    time.Now().UTC(),
+		Host:         hostname(),
+		Organization: orgName,
+		Workspace:    wsName,
+		Variables:    make([]BackupVariable, 0),
+	}
+
+	for _, variable := range ListAllVariables(GetWorkspaceID(orgName, wsName)) {
+		value := variable.Value
+		if variable.Sensitive {
+			value = values[variable.Key]
+		}
+		backup.Variables = append(backup.Variables, BackupVariable{
+			Key:         variable.Key,
+			Value:       value,
+			Description: variable.Description,
+			Category:    variable.Category,
+			HCL:         variable.HCL,
+			Sensitive:   variable.Sensitive,
+		})
+	}
+	return backup
+}
+
+// MissingValues lists the sensitive variables saved without their value
+func (backup Backup) MissingValues() []string {
+	missing := make([]string, 0)
+	for _, variable := range backup.Variables {
+		if variable.Sensitive && variable.Value == "" {
+			missing = append(missing, variable.Key)
+		}
+	}
+	return missing
+}
+
+// NewVariables converts the variables of the backup so they can be written to a workspace
+func (backup Backup) NewVariables() []NewVariable {
+	variables := make([]NewVariable, 0, len(backup.Variables))
+	for _, variable := range backup.Variables {
+		variables = append(variables, NewVariable{
+			Key:         variable.Key,
+			Value:       variable.Value,
+			Description: variable.Description,
+			Category:    variable.Category,
+			HCL:         variable.HCL,
+			Sensitive:   variable.Sensitive,
+		})
+	}
+	return variables
+}
+
+// WriteBackup encrypts the backup to the age recipients and writes it to a file only the user can read
+func WriteBackup(backup Backup, path string, recipients []age.Recipient) error {
+	if len(recipients) == 0 {
+		return errors.New("no age recipient to encrypt the backup to")
+	}
+
+	content, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	var encrypted bytes.Buffer
+	writer, err := age.Encrypt(&encrypted, recipients...)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(content); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, encrypted.Bytes(), 0600)
+}
+
+// ReadBackup decrypts and reads a backup with the age identities used for variable files
+func ReadBackup(path string) (Backup, error) {
+	var backup Backup
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return backup, fmt.Errorf("failed to read %s: %s", path, err)
+	}
+
+	plaintext, err := decryptAgeFile(content)
+	if err != nil {
+		return backup, fmt.Errorf("failed to decrypt %s: %s", path, err)
+	}
+	if err := json.Unmarshal(plaintext, &backup); err != nil {
+		return backup, fmt.Errorf("failed to parse %s: %s", path, err)
+	}
+	if backup.Version != BackupVersion {
+		return backup, fmt.Errorf("%s has an unsupported backup version %d", path, backup.Version)
+	}
+	return backup, nil
+}
+
+// ParseAgeRecipients parses age recipients given directly or in recipient files with one recipient per line
+func ParseAgeRecipients(recipients []string, recipientFiles []string) ([]age.Recipient, error) {
+	parsed := make([]age.Recipient, 0)
+	for _, recipient := range recipients {
+		parsedRecipient, err := age.ParseX25519Recipient(strings.TrimSpace(recipient))
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, parsedRecipient)
+	}
+
+	for _, path := range recipientFiles {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", path, err)
+		}
+		fileRecipients, err := age.ParseRecipients(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", path, err)
+		}
+		parsed = append(parsed, fileRecipients...)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,85 @@
+package helper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestBackupRoundTrip(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keys.txt")
+	if err := ioutil.WriteFile(keyFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	SetAgeKeyFile(keyFile)
+	defer SetAgeKeyFile("")
+
+	recipientsFile := filepath.Join(dir, "recipients.txt")
+	if err := ioutil.WriteFile(recipientsFile, []byte("# backup key\n"+identity.Recipient().String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	recipients, err := ParseAgeRecipients(nil, []string{recipientsFile})
+	if err != nil || len(recipients) != 1 {
+		t.Fatalf("got %d recipients and error %v", len(recipients), err)
+	}
+
+	backup := Backup{
+		Version:      BackupVersion,
+		CreatedAt:    time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC),
+		Organization: "org",
+		Workspace:    "ws",
+		Variables: []BackupVariable{
+			{Key: "REGION", Value: "us-east-1", Category: tfe.CategoryEnv},
+			{Key: "DB_PASSWORD", Value: "hunter2", Category: tfe.CategoryEnv, Sensitive: true},
+			{Key: "API_TOKEN", Category: tfe.CategoryEnv, Sensitive: true},
+			{Key: "zones", Value: `["a"]`, Description: "zones", Category: tfe.CategoryTerraform, HCL: true},
+		},
+	}
+
+	path := filepath.Join(dir, "backup.age")
+	if err := WriteBackup(backup, path, recipients); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("the backup should only be readable by the user, got %v %v", info.Mode(), err)
+	}
+
+	restored, err := ReadBackup(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored, backup) {
+		t.Errorf("got %+v, want %+v", restored, backup)
+	}
+	if want := []string{"API_TOKEN"}; !reflect.DeepEqual(restored.MissingValues(), want) {
+		t.Errorf("got missing values %v, want %v", restored.MissingValues(), want)
+	}
+
+	variables := restored.NewVariables()
+	if len(variables) != 4 || variables[3].Key != "zones" || !variables[3].HCL || variables[3].Description != "zones" {
+		t.Errorf("unexpected variables %+v", variables)
+	}
+}
+
+func TestWriteBackupWithoutRecipient(t *testing.T) {
+	if err := WriteBackup(Backup{}, filepath.Join(t.TempDir(), "backup.age"), nil); err == nil {
+		t.Fatal("a backup was written without recipient")
+	}
+}
+
+func TestParseAgeRecipientsRejectsInvalidKey(t *testing.T) {
+	if _, err := ParseAgeRecipients([]string{"age1invalid"}, nil); err == nil {
+		t.Fatal("an invalid recipient was accepted")
+	}
+}
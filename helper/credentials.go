@@ -0,0 +1,161 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsimple"
+)
+
+// Config struct has the structure of the terraformrc file
+type Config struct {
+	Credentials       []CredentialConfig       `hcl:"credentials,block"`
+	CredentialsHelper *CredentialsHelperConfig `hcl:"credentials_helper,block"`
+	// Remain holds every other CLI setting, which the tool does not use
+	Remain hcl.Body `hcl:",remain"`
+}
+
+// CredentialConfig defines the component of the credentials block in terraformrc file
+type CredentialConfig struct {
+	App   string `hcl:"app,label"`
+	Token string `hcl:"token"`
+}
+
+// CredentialsHelperConfig defines the component of the credentials_helper block in terraformrc file
+type CredentialsHelperConfig struct {
+	Name string   `hcl:"name,label"`
+	Args []string `hcl:"args,optional"`
+}
+
+// credentialsHelperPrefix is the prefix of every credentials helper executable
+const credentialsHelperPrefix = "terraform-credentials-"
+
+// getToken gets the token for the Terraform Cloud host from the terraformrc file, from the
+// credentials helper configured in that file or from the TF_CLOUD_TOKEN environment variable.
+// A single credentials block is used whatever its label, as it always has been
+func getToken() string {
+	host := hostname()
+
+	terraformrcConfig, found := readTerraformrc()
+	if found {
+		for _, credentials := range terraformrcConfig.Credentials {
+			if credentials.App == host {
+				return credentials.Token
+			}
+		}
+
+		if terraformrcConfig.CredentialsHelper != nil {
+			token, err := runCredentialsHelper(terraformrcConfig.CredentialsHelper, host)
+			if err != nil {
+				log.Fatalf("Failed to get token from credentials helper %q: %s", terraformrcConfig.CredentialsHelper.Name, err)
+			}
+			if token != "" {
+				return token
+			}
+		}
+
+		if len(terraformrcConfig.Credentials) == 1 {
+			return terraformrcConfig.Credentials[0].Token
+		}
+	}
+
+	// Read from environment variable TF_CLOUD_TOKEN
+	return os.Getenv("TF_CLOUD_TOKEN")
+}
+
+// hostname gets the hostname of the Terraform Cloud or Terraform Enterprise instance in use
+func hostname() string {
+	address, err := url.Parse(tfe.DefaultConfig().Address)
+	if err != nil || address.Host == "" {
+		return "app.terraform.io"
+	}
+	return address.Host
+}
+
+// readTerraformrc reads the terraformrc file, either the one set in TF_CLI_CONFIG_FILE or the
+// one under the home directory. The boolean is false when there is no file to read
+func readTerraformrc() (Config, bool) {
+	var terraformrcConfig Config
+
+	// Get token from terraformrc from Linux
+	// TODO: implement this in Windows
+	path := os.Getenv("TF_CLI_CONFIG_FILE")
+	if path == "" {
+		homeDir, _ := os.UserHomeDir()
+		path = filepath.Join(homeDir, ".terraformrc")
+	}
+
+	content, fileReadingError := ioutil.ReadFile(path)
+	if fileReadingError != nil {
+		return terraformrcConfig, false
+	}
+
+	decodeErr := hclsimple.Decode(
+		"terraformrc.hcl", content,
+		nil, &terraformrcConfig,
+	)
+	if decodeErr != nil {
+		log.Fatalf("Failed to load configuration: %s", decodeErr)
+	}
+	return terraformrcConfig, true
+}
+
+// runCredentialsHelper calls the credentials helper with "get <hostname>" as described in the
+// Terraform credentials helper protocol and returns the token it prints. An empty token means
+// the helper has no credentials for the host
+func runCredentialsHelper(helperConfig *CredentialsHelperConfig, host string) (string, error) {
+	executable, err := findCredentialsHelper(helperConfig.Name)
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	helperCmd := exec.Command(executable, append(helperConfig.Args, "get", host)...)
+	helperCmd.Stdout = &stdout
+	helperCmd.Stderr = &stderr
+	if err := helperCmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return "", fmt.Errorf("invalid response from credentials helper: %s", err)
+	}
+	return result.Token, nil
+}
+
+// findCredentialsHelper looks for the credentials helper executable in the same plugin directories
+// Terraform searches, then in the PATH
+func findCredentialsHelper(name string) (string, error) {
+	executableName := credentialsHelperPrefix + name
+	homeDir, _ := os.UserHomeDir()
+	pluginDir := filepath.Join(homeDir, ".terraform.d", "plugins")
+
+	for _, dir := range []string{filepath.Join(pluginDir, runtime.GOOS+"_"+runtime.GOARCH), pluginDir} {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			// Helpers can have a version suffix such as terraform-credentials-example_v1.0.0
+			if file.Name() == executableName || strings.HasPrefix(file.Name(), executableName+"_") {
+				return filepath.Join(dir, file.Name()), nil
+			}
+		}
+	}
+
+	return exec.LookPath(executableName)
+}
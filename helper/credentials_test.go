@@ -0,0 +1,115 @@
+package helper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestTerraformrc writes a CLI config file, points TF_CLI_CONFIG_FILE to it and empties the home directory
+// so that no other file or plugin directory is read
+func writeTestTerraformrc(t *testing.T, content string) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "terraformrc")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	setEnv(t, "TF_CLI_CONFIG_FILE", path)
+	setEnv(t, "HOME", dir)
+}
+
+func TestGetToken(t *testing.T) {
+	// The fake helper prints a token for app.terraform.io only, and records its arguments
+	binDir := t.TempDir()
+	argsFile := filepath.Join(binDir, "args")
+	script := `#!/bin/sh
+echo "$@" > "` + argsFile + `"
+case "$*" in
+  *"get app.terraform.io") echo '{"token":"from-helper"}' ;;
+  *) echo '{}' ;;
+esac
+`
+	if err := ioutil.WriteFile(filepath.Join(binDir, "terraform-credentials-x"), []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	setEnv(t, "PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	setEnv(t, "TFE_ADDRESS", "https://app.terraform.io")
+	setEnv(t, "TF_CLOUD_TOKEN", "from-env")
+
+	tests := []struct {
+		name        string
+		terraformrc string
+		address     string
+		want        string
+		wantArgs    string
+	}{
+		{
+			name: "block for the host",
+			terraformrc: `
+credentials "tfe.example.com" {
+  token = "other"
+}
+credentials "app.terraform.io" {
+  token = "from-file"
+}`,
+			want: "from-file",
+		},
+		{
+			name: "single block labeled with another host",
+			terraformrc: `
+credentials "terraform.example.com" {
+  token = "from-file"
+}`,
+			want: "from-file",
+		},
+		{
+			name: "credentials helper",
+			terraformrc: `
+credentials_helper "x" {
+  args = ["--profile", "ci"]
+}`,
+			want:     "from-helper",
+			wantArgs: "--profile ci get app.terraform.io",
+		},
+		{
+			name: "credentials helper without credentials for the host",
+			terraformrc: `
+credentials_helper "x" {
+  args = ["--profile", "ci"]
+}`,
+			address:  "https://tfe.example.com",
+			want:     "from-env",
+			wantArgs: "--profile ci get tfe.example.com",
+		},
+		{
+			name: "several blocks for other hosts",
+			terraformrc: `
+credentials "tfe.example.com" {
+  token = "one"
+}
+credentials "terraform.example.com" {
+  token = "two"
+}`,
+			want: "from-env",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.Remove(argsFile)
+			writeTestTerraformrc(t, test.terraformrc)
+			if test.address != "" {
+				setEnv(t, "TFE_ADDRESS", test.address)
+			}
+
+			if got := getToken(); got != test.want {
+				t.Errorf("got token %q, want %q", got, test.want)
+			}
+			args, _ := ioutil.ReadFile(argsFile)
+			if got := string(args); test.wantArgs != "" && got != test.wantArgs+"\n" {
+				t.Errorf("got helper arguments %q, want %q", got, test.wantArgs)
+			}
+		})
+	}
+}
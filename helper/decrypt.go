@@ -0,0 +1,135 @@
+package helper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	sopsage "go.mozilla.org/sops/v3/age"
+	"go.mozilla.org/sops/v3/cmd/sops/formats"
+	"go.mozilla.org/sops/v3/decrypt"
+	"gopkg.in/yaml.v2"
+)
+
+var ageKeyFile string
+
+// SetAgeKeyFile sets the file with the age identities used to decrypt variable files, with age or SOPS.
+// When it is not set, SOPS_AGE_KEY_FILE, SOPS_AGE_KEY and then the default SOPS key file are used
+func SetAgeKeyFile(path string) {
+	ageKeyFile = path
+}
+
+// ageKey reads the age identities from the same places as SOPS, unless a key file is set
+func ageKey() ([]byte, error) {
+	path := ageKeyFile
+	if path == "" {
+		path = os.Getenv("SOPS_AGE_KEY_FILE")
+	}
+	if path == "" {
+		if key := os.Getenv("SOPS_AGE_KEY"); key != "" {
+			return []byte(key), nil
+		}
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, errors.New("no age key found, please set --age-key-file or SOPS_AGE_KEY_FILE")
+		}
+		path = filepath.Join(configDir, "sops", "age", "keys.txt")
+	}
+
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read age key: %s", err)
+	}
+	return key, nil
+}
+
+// ageIdentities parses the age identities found by ageKey
+func ageIdentities() ([]age.Identity, error) {
+	key, err := ageKey()
+	if err != nil {
+		return nil, err
+	}
+	return age.ParseIdentities(bytes.NewReader(key))
+}
+
+// decryptAgeFile decrypts a binary or ASCII armored age file in memory
+func decryptAgeFile(content []byte) ([]byte, error) {
+	identities, err := ageIdentities()
+	if err != nil {
+		return nil, err
+	}
+	return decryptAge(content, identities)
+}
+
+// decryptAge decrypts binary or ASCII armored age content with the identities
+func decryptAge(content []byte, identities []age.Identity) ([]byte, error) {
+	var src io.Reader = bytes.NewReader(content)
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(bytes.TrimSpace(content)))
+	}
+
+	plaintext, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(plaintext)
+}
+
+// sopsMutex guards SOPS_AGE_KEY while SOPS decrypts a file
+var sopsMutex sync.Mutex
+
+// decryptSOPSFile decrypts a file encrypted by SOPS in memory. The format is taken from the name the way
+// the sops command does. SOPS reads its age identities from SOPS_AGE_KEY, so it is set to the identities
+// found by ageKey during the decryption. The other key types, such as KMS or PGP, are read by SOPS itself
+func decryptSOPSFile(content []byte, name string) ([]byte, error) {
+	sopsMutex.Lock()
+	defer sopsMutex.Unlock()
+
+	if key, err := ageKey(); err == nil {
+		previous, set := os.LookupEnv(sopsage.SopsAgeKeyEnv)
+		if err := os.Setenv(sopsage.SopsAgeKeyEnv, string(key)); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if set {
+				os.Setenv(sopsage.SopsAgeKeyEnv, previous)
+			} else {
+				os.Unsetenv(sopsage.SopsAgeKeyEnv)
+			}
+		}()
+	}
+	return decrypt.DataWithFormat(content, formats.FormatForPath(name))
+}
+
+// isSOPSFile checks whether a variable file was encrypted by SOPS
+func isSOPSFile(content []byte, name string) bool {
+	if variableFileFormat(name) == formatDotenv {
+		dotenv := string(content)
+		return strings.Contains(dotenv, "sops_version=") || strings.Contains(dotenv, "sops_mac=")
+	}
+
+	var tree yaml.MapSlice
+	if err := yaml.Unmarshal(content, &tree); err != nil {
+		return false
+	}
+	_, found := mapSliceValue(tree, "sops")
+	return found
+}
+
+// mapSliceValue gets the value of a key in a YAML map
+func mapSliceValue(tree yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range tree {
+		if fmt.Sprint(item.Key) == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,229 @@
+package helper
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/hashicorp/go-tfe"
+	"go.mozilla.org/sops/v3"
+	"go.mozilla.org/sops/v3/aes"
+	sopsage "go.mozilla.org/sops/v3/age"
+	"go.mozilla.org/sops/v3/cmd/sops/common"
+	"go.mozilla.org/sops/v3/cmd/sops/formats"
+	"go.mozilla.org/sops/v3/keyservice"
+	"go.mozilla.org/sops/v3/version"
+)
+
+// encryptTestAge encrypts content to the recipient, ASCII armored or not
+func encryptTestAge(t *testing.T, content string, recipient age.Recipient, armored bool) []byte {
+	var out bytes.Buffer
+	var dst io.Writer = &out
+	var armorWriter io.WriteCloser
+	if armored {
+		armorWriter = armor.NewWriter(&out)
+		dst = armorWriter
+	}
+	writer, err := age.Encrypt(dst, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(writer, content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if armorWriter != nil {
+		if err := armorWriter.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return out.Bytes()
+}
+
+func TestLoadVariableFileDecryptsAge(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keys.txt")
+	if err := ioutil.WriteFile(keyFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	SetAgeKeyFile(keyFile)
+	defer SetAgeKeyFile("")
+
+	tests := []struct {
+		name    string
+		file    string
+		content []byte
+		want    []NewVariable
+		wantErr bool
+	}{
+		{
+			name:    "binary dotenv",
+			file:    "secrets.env.age",
+			content: encryptTestAge(t, "DB_PASSWORD=s3cr3t\n", identity.Recipient(), false),
+			want:    []NewVariable{{Key: "DB_PASSWORD", Value: "s3cr3t", Category: tfe.CategoryEnv, Sensitive: true}},
+		},
+		{
+			name:    "armored tfvars",
+			file:    "prod.tfvars.age",
+			content: encryptTestAge(t, "db_password = \"s3cr3t\"\n", identity.Recipient(), true),
+			want:    []NewVariable{{Key: "db_password", Value: "s3cr3t", Category: tfe.CategoryTerraform, Sensitive: true}},
+		},
+		{
+			name:    "other recipient",
+			file:    "secrets.env.age",
+			content: encryptTestAge(t, "DB_PASSWORD=s3cr3t\n", other.Recipient(), false),
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.file)
+			if err := ioutil.WriteFile(path, test.content, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadVariableFile(path, NewVariable{Category: tfe.CategoryEnv})
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+// encryptTestSOPS encrypts content to the age recipient the way the sops command does for the file name
+func encryptTestSOPS(t *testing.T, name string, content string, recipient string) []byte {
+	store := common.StoreForFormat(formats.FormatForPath(name))
+	branches, err := store.LoadPlainFile([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := sopsage.MasterKeysFromRecipients(recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := make(sops.KeyGroup, 0, len(keys))
+	for _, key := range keys {
+		group = append(group, key)
+	}
+
+	tree := sops.Tree{Branches: branches, Metadata: sops.Metadata{KeyGroups: []sops.KeyGroup{group}, Version: version.Version}}
+	dataKey, errs := tree.GenerateDataKeyWithKeyServices([]keyservice.KeyServiceClient{keyservice.NewLocalClient()})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if err := common.EncryptTree(common.EncryptTreeOpts{Tree: &tree, Cipher: aes.NewCipher(), DataKey: dataKey}); err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := store.EmitEncryptedFile(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encrypted
+}
+
+func TestLoadVariableFileDecryptsSOPS(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keys.txt")
+	if err := ioutil.WriteFile(keyFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	SetAgeKeyFile(keyFile)
+	defer SetAgeKeyFile("")
+	// The key file takes precedence over the key SOPS would read by itself
+	setEnv(t, "SOPS_AGE_KEY", other.String())
+
+	recipient := identity.Recipient().String()
+	tests := []struct {
+		name    string
+		file    string
+		content []byte
+		want    []NewVariable
+		wantErr bool
+	}{
+		{
+			name:    "dotenv",
+			file:    "secrets.env",
+			content: encryptTestSOPS(t, "secrets.env", "DB_PASSWORD=s3cr3t\n", recipient),
+			want:    []NewVariable{{Key: "DB_PASSWORD", Value: "s3cr3t", Category: tfe.CategoryEnv, Sensitive: true}},
+		},
+		{
+			name:    "manifest",
+			file:    "secrets.yaml",
+			content: encryptTestSOPS(t, "secrets.yaml", "variables:\n  - key: region\n    value: us-east-1\n    category: terraform\n", recipient),
+			want:    []NewVariable{{Key: "region", Value: "us-east-1", Category: tfe.CategoryTerraform, Sensitive: true}},
+		},
+		{
+			name:    "tfvars",
+			file:    "prod.tfvars",
+			content: encryptTestSOPS(t, "prod.tfvars", "db_password = \"s3cr3t\"\n", recipient),
+			want:    []NewVariable{{Key: "db_password", Value: "s3cr3t", Category: tfe.CategoryTerraform, Sensitive: true}},
+		},
+		{
+			name:    "other recipient",
+			file:    "other.env",
+			content: encryptTestSOPS(t, "other.env", "DB_PASSWORD=s3cr3t\n", other.Recipient().String()),
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.file)
+			if err := ioutil.WriteFile(path, test.content, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			// The encrypted values must never be read as plain values
+			got, err := LoadVariableFile(path, NewVariable{Category: tfe.CategoryEnv})
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+			if value, found := os.LookupEnv("SOPS_AGE_KEY"); !found || value != other.String() {
+				t.Error("SOPS_AGE_KEY was not restored")
+			}
+		})
+	}
+}
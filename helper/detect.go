@@ -0,0 +1,154 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Policies applied to the variables that look like secrets but are not sensitive
+const (
+	SecretPolicyWarn   = "warn"
+	SecretPolicyForce  = "force"
+	SecretPolicyRefuse = "refuse"
+	SecretPolicyOff    = "off"
+)
+
+// DefaultSecretPatterns are the key patterns of the variables that are treated as secrets
+var DefaultSecretPatterns = []string{"*SECRET*", "*TOKEN*", "*PASSWORD*", "*PRIVATE_KEY*", "*CREDENTIALS*"}
+
+// SecretDetection configures how variables that look like secrets are found and handled
+type SecretDetection struct {
+	// Policy is warn, force, refuse or off
+	Policy string
+	// Patterns are glob patterns matched against the keys, ignoring the case
+	Patterns []string
+	// MinEntropy is the Shannon entropy in bits per character above which a value looks random. 0 disables the check
+	MinEntropy float64
+	// AllowPlaintext has the keys that are never treated as secrets, ignoring the case
+	AllowPlaintext []string
+}
+
+// minEntropyLength is the length under which values are too short for the entropy check to be meaningful
+const minEntropyLength = 20
+
+// shortValueEntropyRatio is the part of the highest possible entropy a short value needs to look random.
+// A value of n characters has at most log2(n) bits per character, below 4.5 for up to 22 characters
+const shortValueEntropyRatio = 0.9
+
+// hexValuePattern matches hashes, UUIDs and other IDs made of hexadecimal digits
+var hexValuePattern = regexp.MustCompile(`^[0-9a-fA-F-]+$`)
+
+var secretDetection = SecretDetection{Policy: SecretPolicyRefuse, Patterns: DefaultSecretPatterns, MinEntropy: 4.5}
+
+// SetSecretDetection sets how variables that look like secrets are found and handled
+func SetSecretDetection(detection SecretDetection) error {
+	switch detection.Policy {
+	case SecretPolicyWarn, SecretPolicyForce, SecretPolicyRefuse, SecretPolicyOff:
+	default:
+		return fmt.Errorf("invalid secret policy %q, it should be warn, force, refuse or off", detection.Policy)
+	}
+	for _, pattern := range detection.Patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid secret pattern %q: %s", pattern, err)
+		}
+	}
+	secretDetection = detection
+	return nil
+}
+
+// LooksLikeSecret checks whether a variable looks like a secret from its key or its value and gives the reason
+func LooksLikeSecret(key string, value string) (bool, string) {
+	for _, allowedKey := range secretDetection.AllowPlaintext {
+		if strings.EqualFold(allowedKey, key) {
+			return false, ""
+		}
+	}
+
+	upperKey := strings.ToUpper(key)
+	for _, pattern := range secretDetection.Patterns {
+		if matched, _ := path.Match(strings.ToUpper(pattern), upperKey); matched {
+			return true, fmt.Sprintf("key matches %s", pattern)
+		}
+	}
+
+	if secretDetection.MinEntropy > 0 && looksRandom(value) {
+		return true, "value looks random"
+	}
+	return false, ""
+}
+
+// looksRandom checks whether a value has the length and entropy of a generated secret.
+// Values with spaces, URLs, ARNs, hashes and UUIDs are skipped because they are rarely secrets
+func looksRandom(value string) bool {
+	if len(value) < minEntropyLength || strings.ContainsAny(value, " \t\n") ||
+		strings.Contains(value, "://") || strings.HasPrefix(value, "arn:") || hexValuePattern.MatchString(value) {
+		return false
+	}
+	return shannonEntropy(value) >= entropyThreshold(len(value))
+}
+
+// entropyThreshold gets the entropy a value of the length needs to look random. The threshold is
+// lowered for values too short to ever reach it
+func entropyThreshold(length int) float64 {
+	return math.Min(secretDetection.MinEntropy, shortValueEntropyRatio*math.Log2(float64(length)))
+}
+
+// shannonEntropy computes the Shannon entropy of a value in bits per character
+func shannonEntropy(value string) float64 {
+	counts := make(map[rune]float64)
+	total := 0.0
+	for _, character := range value {
+		counts[character]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		probability := count / total
+		entropy -= probability * math.Log2(probability)
+	}
+	return entropy
+}
+
+// CheckSecrets applies the secret policy to the variables that look like secrets but are not sensitive.
+// With the warn policy they are sent unchanged with a warning, with the force policy they are marked
+// as sensitive and with the refuse policy an error lists all of them
+func CheckSecrets(variables []NewVariable) ([]NewVariable, error) {
+	if secretDetection.Policy == SecretPolicyOff {
+		return variables, nil
+	}
+
+	refused := make([]string, 0)
+	for i, variable := range variables {
+		if variable.Sensitive {
+			continue
+		}
+		isSecret, reason := LooksLikeSecret(variable.Key, variable.Value)
+		if !isSecret {
+			continue
+		}
+
+		switch secretDetection.Policy {
+		case SecretPolicyWarn:
+			fmt.Fprintf(os.Stderr, "Warning: %s looks like a secret but is not sensitive (%s), use -s or --secret-policy force to send it as sensitive\n", variable.Key, reason)
+		case SecretPolicyForce:
+			fmt.Fprintf(os.Stderr, "Marking %s as sensitive: %s\n", variable.Key, reason)
+			variables[i].Sensitive = true
+		default:
+			refused = append(refused, fmt.Sprintf("%s (%s)", variable.Key, reason))
+		}
+	}
+
+	if len(refused) > 0 {
+		sort.Strings(refused)
+		return variables, errors.New(`these variables look like secrets but are not sensitive: ` + strings.Join(refused, ", ") + `
+Please use -s or --secret-policy force, or --allow-plaintext with the keys that are not secrets`)
+	}
+	return variables, nil
+}
@@ -0,0 +1,98 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLooksLikeSecret(t *testing.T) {
+	defer SetSecretDetection(secretDetection)
+	if err := SetSecretDetection(SecretDetection{
+		Policy:         SecretPolicyForce,
+		Patterns:       DefaultSecretPatterns,
+		MinEntropy:     4.5,
+		AllowPlaintext: []string{"GITHUB_TOKEN_NAME"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		key   string
+		value string
+		want  bool
+	}{
+		{name: "key pattern", key: "DB_PASSWORD", value: "x", want: true},
+		{name: "key pattern ignores case", key: "api_token", value: "x", want: true},
+		{name: "allowed key", key: "GITHUB_TOKEN_NAME", value: "x", want: false},
+		{name: "allowed key ignores case", key: "github_token_name", value: "x", want: false},
+		{name: "plain value", key: "REGION", value: "us-east-1", want: false},
+		{name: "plain long value", key: "NAME", value: "my-application-name-prod", want: false},
+		{name: "random value of 20 characters", key: "KEY", value: "aZ3kP9qL2xW7mN4bV8cR", want: true},
+		{name: "random value of 22 characters", key: "KEY", value: "Tq8vL1zY6pK3sD9fH2jG5w", want: true},
+		{name: "random value of 40 characters", key: "KEY", value: "wJalrXUtnFEMIK7MDENGbPxRfiCYEXAMPLEKEY1a", want: true},
+		{name: "random value too short", key: "KEY", value: "aZ3kP9qL2xW7", want: false},
+		{name: "random value with spaces", key: "KEY", value: "aZ3k P9qL 2xW7 mN4b V8cR", want: false},
+		{name: "url", key: "KEY", value: "https://aZ3kP9qL2xW7mN4bV8cR.example.com", want: false},
+		{name: "arn", key: "KEY", value: "arn:aws:iam::123456789012:role/aZ3kP9qL2xW7", want: false},
+		{name: "sha1 hash", key: "COMMIT", value: "3f786850e387550fdab836ed7e6dc881de23001b", want: false},
+		{name: "uuid", key: "TENANT_ID", value: "72f988bf-86f1-41af-91ab-2d7cd011db47", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, reason := LooksLikeSecret(test.key, test.value); got != test.want {
+				t.Errorf("LooksLikeSecret(%q, %q) = %v (%s), want %v", test.key, test.value, got, reason, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckSecrets(t *testing.T) {
+	defer SetSecretDetection(secretDetection)
+
+	variables := func() []NewVariable {
+		return []NewVariable{
+			{Key: "DB_PASSWORD", Value: "hunter2"},
+			{Key: "REGION", Value: "us-east-1"},
+			{Key: "API_TOKEN", Value: "abc", Sensitive: true},
+		}
+	}
+
+	tests := []struct {
+		policy        string
+		wantSensitive []bool
+		wantErr       string
+	}{
+		{policy: SecretPolicyWarn, wantSensitive: []bool{false, false, true}},
+		{policy: SecretPolicyForce, wantSensitive: []bool{true, false, true}},
+		{policy: SecretPolicyRefuse, wantSensitive: []bool{false, false, true}, wantErr: "DB_PASSWORD (key matches *PASSWORD*)"},
+		{policy: SecretPolicyOff, wantSensitive: []bool{false, false, true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy, func(t *testing.T) {
+			if err := SetSecretDetection(SecretDetection{Policy: test.policy, Patterns: DefaultSecretPatterns, MinEntropy: 4.5}); err != nil {
+				t.Fatal(err)
+			}
+			checked, err := CheckSecrets(variables())
+			if test.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+				t.Fatalf("got error %v, want %q", err, test.wantErr)
+			}
+			for i, variable := range checked {
+				if variable.Sensitive != test.wantSensitive[i] {
+					t.Errorf("%s: got sensitive %v, want %v", variable.Key, variable.Sensitive, test.wantSensitive[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSetSecretDetectionRejectsInvalidPolicy(t *testing.T) {
+	if err := SetSecretDetection(SecretDetection{Policy: "mask"}); err == nil {
+		t.Fatal("an invalid policy was accepted")
+	}
+}
@@ -0,0 +1,85 @@
+package helper
+
+import (
+	"sort"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// SourceWorkspace is the source of the variables set in the workspace itself
+const SourceWorkspace = "workspace"
+
+// EffectiveVariable is a variable a run of the workspace sees, with the source it comes from
+// and the sources with the same variable it takes precedence over
+type EffectiveVariable struct {
+	Variable *tfe.Variable
+	Source   string
+	Shadowed []string
+}
+
+// variableSource is a list of variables and where they come from, in order of precedence
+type variableSource struct {
+	name      string
+	variables []*tfe.Variable
+}
+
+// EffectiveVariables merges the variables of a workspace with the variable sets attached to it and the
+// global variable sets of the organization, the way a run sees them. Workspace variables win over variable sets,
+// sets attached to the workspace win over global sets, and between sets of the same kind the name first
+// in lexical order wins. Terraform and environment variables with the same key do not conflict
+func EffectiveVariables(orgName string, workspaceID string) ([]EffectiveVariable, error) {
+	workspaceVariables, err := listVariables(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	sets, err := ListVariableSets(orgName)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Name < sets[j].Name })
+
+	sources := []variableSource{{name: SourceWorkspace, variables: workspaceVariables}}
+	globalSources := make([]variableSource, 0)
+	for _, set := range sets {
+		attached := false
+		for _, id := range set.WorkspaceIDs {
+			attached = attached || id == workspaceID
+		}
+		if !attached && !set.Global {
+			continue
+		}
+
+		variables, err := ListVariableSetVariables(set)
+		if err != nil {
+			return nil, err
+		}
+		if attached {
+			sources = append(sources, variableSource{name: "set " + set.Name, variables: variables})
+		} else {
+			globalSources = append(globalSources, variableSource{name: "global set " + set.Name, variables: variables})
+		}
+	}
+	sources = append(sources, globalSources...)
+
+	effective := make([]EffectiveVariable, 0)
+	positions := make(map[string]int)
+	for _, source := range sources {
+		for _, variable := range source.variables {
+			id := string(variable.Category) + "/" + variable.Key
+			if position, found := positions[id]; found {
+				effective[position].Shadowed = append(effective[position].Shadowed, source.name)
+				continue
+			}
+			positions[id] = len(effective)
+			effective = append(effective, EffectiveVariable{Variable: variable, Source: source.name, Shadowed: make([]string, 0)})
+		}
+	}
+
+	sort.SliceStable(effective, func(i, j int) bool {
+		if effective[i].Variable.Category != effective[j].Variable.Category {
+			return effective[i].Variable.Category < effective[j].Variable.Category
+		}
+		return effective[i].Variable.Key < effective[j].Variable.Key
+	})
+	return effective, nil
+}
@@ -0,0 +1,82 @@
+package helper
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestEffectiveVariables(t *testing.T) {
+	terraform := func(id string, key string, value string) *tfe.Variable {
+		return &tfe.Variable{ID: id, Key: key, Value: value, Category: tfe.CategoryTerraform}
+	}
+	env := func(id string, key string, value string) *tfe.Variable {
+		return &tfe.Variable{ID: id, Key: key, Value: value, Category: tfe.CategoryEnv}
+	}
+	variables := map[string][]*tfe.Variable{
+		"workspaces/ws-1/vars":                       {terraform("var-1", "instance_count", "3"), env("var-2", "region", "workspace")},
+		"varsets/varset-b/relationships/vars":        {terraform("var-3", "region", "b"), terraform("var-4", "tags", "b")},
+		"varsets/varset-a/relationships/vars":        {terraform("var-5", "region", "a"), env("var-6", "DB_HOST", "a")},
+		"varsets/varset-z/relationships/vars":        {terraform("var-7", "owner", "z")},
+		"varsets/varset-g/relationships/vars":        {terraform("var-8", "instance_count", "g"), env("var-9", "DB_HOST", "g"), terraform("var-10", "owner", "g")},
+		"varsets/varset-other/relationships/vars":    {terraform("var-11", "unrelated", "other")},
+		"varsets/varset-b-global/relationships/vars": {terraform("var-12", "tags", "global")},
+	}
+	requests := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, tfe.DefaultBasePath)
+		if path == "organizations/acme/varsets" {
+			// The sets are not sorted by name
+			writeTestVariableSets(w,
+				"varset-z:z-global:true:",
+				"varset-b:b-attached:false:ws-1",
+				"varset-other:other:false:ws-2",
+				"varset-g:global-defaults:true:",
+				"varset-a:a-attached:false:ws-2,ws-1",
+				"varset-b-global:b-global:true:",
+			)
+			return
+		}
+		writeTestVariables(w, variables[path]...)
+	})
+
+	effective, err := EffectiveVariables("acme", "ws-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Workspace variables win, then the attached sets and then the global sets, by name within each kind
+	want := []EffectiveVariable{
+		{Variable: env("var-6", "DB_HOST", "a"), Source: "set a-attached", Shadowed: []string{"global set global-defaults"}},
+		{Variable: env("var-2", "region", "workspace"), Source: SourceWorkspace, Shadowed: []string{}},
+		{Variable: terraform("var-1", "instance_count", "3"), Source: SourceWorkspace, Shadowed: []string{"global set global-defaults"}},
+		{Variable: terraform("var-10", "owner", "g"), Source: "global set global-defaults", Shadowed: []string{"global set z-global"}},
+		{Variable: terraform("var-5", "region", "a"), Source: "set a-attached", Shadowed: []string{"set b-attached"}},
+		{Variable: terraform("var-4", "tags", "b"), Source: "set b-attached", Shadowed: []string{"global set b-global"}},
+	}
+	if !reflect.DeepEqual(effective, want) {
+		t.Errorf("got:")
+		for _, variable := range effective {
+			t.Errorf("  %s %s=%s from %s over %v", variable.Variable.Category, variable.Variable.Key, variable.Variable.Value, variable.Source, variable.Shadowed)
+		}
+	}
+
+	// The variables of a set neither attached nor global are never read
+	got := requests()
+	sort.Strings(got)
+	wantRequests := []string{
+		"GET organizations/acme/varsets",
+		"GET varsets/varset-a/relationships/vars",
+		"GET varsets/varset-b-global/relationships/vars",
+		"GET varsets/varset-b/relationships/vars",
+		"GET varsets/varset-g/relationships/vars",
+		"GET varsets/varset-z/relationships/vars",
+		"GET workspaces/ws-1/vars",
+	}
+	if !reflect.DeepEqual(got, wantRequests) {
+		t.Errorf("got requests %v, want %v", got, wantRequests)
+	}
+}
@@ -0,0 +1,54 @@
+package helper
+
+import (
+	"sort"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// CommonVariables finds the non-sensitive variables that every workspace has with the same value and attributes.
+// They are the variables a variable set can hold instead of the workspaces
+func CommonVariables(results []WorkspaceVariables) []NewVariable {
+	if len(results) == 0 {
+		return nil
+	}
+
+	common := make([]NewVariable, 0)
+	for _, variable := range results[0].Variables {
+		if variable.Sensitive {
+			continue
+		}
+		candidate := NewVariable{
+			Key:         variable.Key,
+			Value:       variable.Value,
+			Description: variable.Description,
+			Category:    variable.Category,
+			HCL:         variable.HCL,
+		}
+
+		shared := true
+		for _, result := range results[1:] {
+			other := findVariableInCategory(result.Variables, candidate.Key, candidate.Category)
+			if other == nil || other.Sensitive || other.Value != candidate.Value || other.Description != candidate.Description || other.HCL != candidate.HCL {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			common = append(common, candidate)
+		}
+	}
+
+	sort.Slice(common, func(i, j int) bool { return common[i].Key < common[j].Key })
+	return common
+}
+
+// findVariableInCategory finds the variable with the key and the category. It returns nil when there is none
+func findVariableInCategory(variables []*tfe.Variable, key string, category tfe.CategoryType) *tfe.Variable {
+	for _, variable := range variables {
+		if variable.Key == key && variable.Category == category {
+			return variable
+		}
+	}
+	return nil
+}
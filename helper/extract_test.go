@@ -0,0 +1,83 @@
+package helper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestCommonVariables(t *testing.T) {
+	region := func(value string) *tfe.Variable {
+		return &tfe.Variable{Key: "REGION", Value: value, Category: tfe.CategoryEnv}
+	}
+
+	tests := []struct {
+		name       string
+		workspaces [][]*tfe.Variable
+		want       []NewVariable
+	}{
+		{
+			name: "no workspace",
+		},
+		{
+			name:       "shared by every workspace",
+			workspaces: [][]*tfe.Variable{{region("us-east-1")}, {region("us-east-1")}, {region("us-east-1")}},
+			want:       []NewVariable{{Key: "REGION", Value: "us-east-1", Category: tfe.CategoryEnv}},
+		},
+		{
+			name:       "missing in a workspace",
+			workspaces: [][]*tfe.Variable{{region("us-east-1")}, {region("us-east-1")}, {}},
+			want:       []NewVariable{},
+		},
+		{
+			name:       "different value",
+			workspaces: [][]*tfe.Variable{{region("us-east-1")}, {region("eu-west-1")}},
+			want:       []NewVariable{},
+		},
+		{
+			name: "different category",
+			workspaces: [][]*tfe.Variable{
+				{region("us-east-1")},
+				{{Key: "REGION", Value: "us-east-1", Category: tfe.CategoryTerraform}},
+			},
+			want: []NewVariable{},
+		},
+		{
+			name: "different description or HCL flag",
+			workspaces: [][]*tfe.Variable{
+				{{Key: "A", Value: "1", Description: "a", Category: tfe.CategoryEnv}, {Key: "B", Value: "[]", HCL: true, Category: tfe.CategoryTerraform}},
+				{{Key: "A", Value: "1", Category: tfe.CategoryEnv}, {Key: "B", Value: "[]", Category: tfe.CategoryTerraform}},
+			},
+			want: []NewVariable{},
+		},
+		{
+			name: "sensitive variables are never shared",
+			workspaces: [][]*tfe.Variable{
+				{{Key: "TOKEN", Category: tfe.CategoryEnv, Sensitive: true}, {Key: "B", Value: "x", Category: tfe.CategoryEnv}},
+				{{Key: "TOKEN", Category: tfe.CategoryEnv, Sensitive: true}, {Key: "B", Value: "x", Category: tfe.CategoryEnv, Sensitive: true}},
+			},
+			want: []NewVariable{},
+		},
+		{
+			name: "sorted by key",
+			workspaces: [][]*tfe.Variable{
+				{{Key: "Z", Value: "1", Category: tfe.CategoryEnv}, {Key: "A", Value: "2", Category: tfe.CategoryEnv}},
+				{{Key: "A", Value: "2", Category: tfe.CategoryEnv}, {Key: "Z", Value: "1", Category: tfe.CategoryEnv}},
+			},
+			want: []NewVariable{{Key: "A", Value: "2", Category: tfe.CategoryEnv}, {Key: "Z", Value: "1", Category: tfe.CategoryEnv}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := make([]WorkspaceVariables, 0)
+			for i, variables := range test.workspaces {
+				results = append(results, WorkspaceVariables{Workspace: &tfe.Workspace{ID: string(rune('a' + i))}, Variables: variables})
+			}
+			if got := CommonVariables(results); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/yaml.v2"
+)
+
+// Manifest is the structure of a manifest file listing variables and their attributes
+type Manifest struct {
+	Variables []ManifestVariable `yaml:"variables"`
+}
+
+// ManifestVariable is a single variable in a manifest file.
+// Attributes that are not set fall back to the values given with the flags
+type ManifestVariable struct {
+	Key         string  `yaml:"key"`
+	Value       string  `yaml:"value"`
+	Description *string `yaml:"description,omitempty"`
+	Category    *string `yaml:"category,omitempty"`
+	HCL         *bool   `yaml:"hcl,omitempty"`
+	Sensitive   *bool   `yaml:"sensitive,omitempty"`
+}
+
+// Formats of the variable files
+const (
+	formatTfvars     = "tfvars"
+	formatTfvarsJSON = "tfvars.json"
+	formatDotenv     = "dotenv"
+	formatManifest   = "manifest"
+)
+
+// LoadVariableFile reads the variables in a tfvars, dotenv or manifest (YAML or JSON) file.
+// defaults has the description, category and flags given to the variables that do not set them.
+// Files encrypted with age or SOPS are decrypted in memory and their variables are always sensitive
+func LoadVariableFile(path string, defaults NewVariable) ([]NewVariable, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", path, err)
+	}
+
+	name := path
+	encrypted := false
+	if strings.HasSuffix(name, ".age") {
+		name = strings.TrimSuffix(name, ".age")
+		if content, err = decryptAgeFile(content); err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %s", path, err)
+		}
+		encrypted = true
+	}
+
+	if isSOPSFile(content, name) {
+		if content, err = decryptSOPSFile(content, name); err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s with SOPS: %s", path, err)
+		}
+		encrypted = true
+	}
+	format := variableFileFormat(name)
+
+	variables, err := parseVariableFile(content, format, defaults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", path, err)
+	}
+
+	if encrypted {
+		for i := range variables {
+			variables[i].Sensitive = true
+		}
+	}
+	return variables, nil
+}
+
+// variableFileFormat gets the format of a variable file from its name
+func variableFileFormat(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".tfvars.json"):
+		return formatTfvarsJSON
+	case strings.HasSuffix(name, ".tfvars"):
+		return formatTfvars
+	case strings.HasSuffix(name, ".yaml"), strings.HasSuffix(name, ".yml"), strings.HasSuffix(name, ".json"):
+		return formatManifest
+	}
+	// Anything else, such as .env files, is read as dotenv
+	return formatDotenv
+}
+
+// parseVariableFile parses the content of a variable file in the given format
+func parseVariableFile(content []byte, format string, defaults NewVariable) ([]NewVariable, error) {
+	switch format {
+	case formatTfvars, formatTfvarsJSON:
+		return parseTfvars(content, format, defaults)
+	case formatManifest:
+		return parseManifest(content, defaults)
+	}
+	return parseDotenv(content, defaults)
+}
+
+// parseTfvars parses a tfvars file. Variables in tfvars files are always terraform variables.
+// Lists and maps are sent in HCL so they keep their type, any other value never is, whatever --hcl says
+func parseTfvars(content []byte, format string, defaults NewVariable) ([]NewVariable, error) {
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if format == formatTfvarsJSON {
+		file, diags = hcljson.Parse(content, "variables.tfvars.json")
+	} else {
+		file, diags = hclsyntax.ParseConfig(content, "variables.tfvars", hcl.Pos{Line: 1, Column: 1})
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	attributes, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	variables := make([]NewVariable, 0, len(attributes))
+	for name, attribute := range attributes {
+		value, diags := attribute.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		variable := defaults
+		variable.Key = name
+		variable.Category = tfe.CategoryTerraform
+		variable.HCL = false
+		if value.Type() == cty.String && value.IsKnown() && !value.IsNull() {
+			variable.Value = value.AsString()
+		} else {
+			exprRange := attribute.Expr.Range()
+			variable.Value = string(exprRange.SliceBytes(content))
+			variable.HCL = !value.Type().IsPrimitiveType()
+		}
+		variables = append(variables, variable)
+	}
+
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Key < variables[j].Key })
+	return variables, nil
+}
+
+// parseDotenv parses a dotenv file with one KEY=value per line.
+// Empty lines, comments and the export keyword are ignored
+func parseDotenv(content []byte, defaults NewVariable) ([]NewVariable, error) {
+	variables := make([]NewVariable, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		pair := strings.SplitN(line, "=", 2)
+		if len(pair) != 2 || strings.TrimSpace(pair[0]) == "" {
+			return nil, fmt.Errorf("line %d is not in the KEY=value format", lineNumber)
+		}
+
+		variable := defaults
+		variable.Key = strings.TrimSpace(pair[0])
+		variable.Value = unquoteDotenvValue(strings.TrimSpace(pair[1]))
+		variables = append(variables, variable)
+	}
+	return variables, scanner.Err()
+}
+
+// unquoteDotenvValue removes the quotes around a dotenv value.
+// Double quoted values can have escaped characters such as \n
+func unquoteDotenvValue(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+	switch {
+	case value[0] == '"' && value[len(value)-1] == '"':
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return value[1 : len(value)-1]
+	case value[0] == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// parseManifest parses a manifest file. JSON manifests are read the same way as YAML manifests
+func parseManifest(content []byte, defaults NewVariable) ([]NewVariable, error) {
+	var manifest Manifest
+	if err := yaml.UnmarshalStrict(content, &manifest); err != nil {
+		return nil, err
+	}
+
+	variables := make([]NewVariable, 0, len(manifest.Variables))
+	for i, manifestVariable := range manifest.Variables {
+		if manifestVariable.Key == "" {
+			return nil, fmt.Errorf("variable #%d has no key", i+1)
+		}
+
+		variable := defaults
+		variable.Key = manifestVariable.Key
+		variable.Value = manifestVariable.Value
+		// Only secret store references are resolved in manifests, other values are always literal
+		if IsSensitiveReference(manifestVariable.Value) {
+			value, err := ResolveValue(manifestVariable.Value)
+			if err != nil {
+				return nil, fmt.Errorf("cannot get the value of %s: %s", variable.Key, err)
+			}
+			variable.Value = value
+		}
+		if manifestVariable.Description != nil {
+			variable.Description = *manifestVariable.Description
+		}
+		if manifestVariable.Category != nil {
+			category := tfe.CategoryType(*manifestVariable.Category)
+			if category != tfe.CategoryEnv && category != tfe.CategoryTerraform {
+				return nil, fmt.Errorf("variable %s has an invalid category %q, it should be env or terraform", variable.Key, category)
+			}
+			variable.Category = category
+		}
+		if manifestVariable.HCL != nil {
+			variable.HCL = *manifestVariable.HCL
+		}
+		if manifestVariable.Sensitive != nil {
+			variable.Sensitive = *manifestVariable.Sensitive
+		}
+		if IsSensitiveReference(manifestVariable.Value) {
+			variable.Sensitive = true
+		}
+		variables = append(variables, variable)
+	}
+	return variables, nil
+}
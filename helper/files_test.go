@@ -0,0 +1,174 @@
+package helper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestParseVariableFile(t *testing.T) {
+	defaults := NewVariable{Description: "from flags", Category: tfe.CategoryEnv}
+
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    []NewVariable
+		wantErr bool
+	}{
+		{
+			name:   "tfvars",
+			format: formatTfvars,
+			content: `region = "us-east-1"
+instance_count = 3
+zones = ["a", "b"]
+tags = {
+  team = "payments"
+}
+`,
+			want: []NewVariable{
+				{Key: "instance_count", Value: "3", Description: "from flags", Category: tfe.CategoryTerraform},
+				{Key: "region", Value: "us-east-1", Description: "from flags", Category: tfe.CategoryTerraform},
+				{Key: "tags", Value: "{\n  team = \"payments\"\n}", Description: "from flags", Category: tfe.CategoryTerraform, HCL: true},
+				{Key: "zones", Value: `["a", "b"]`, Description: "from flags", Category: tfe.CategoryTerraform, HCL: true},
+			},
+		},
+		{
+			name:    "tfvars json",
+			format:  formatTfvarsJSON,
+			content: `{"region": "us-east-1", "zones": ["a", "b"]}`,
+			want: []NewVariable{
+				{Key: "region", Value: "us-east-1", Description: "from flags", Category: tfe.CategoryTerraform},
+				{Key: "zones", Value: `["a", "b"]`, Description: "from flags", Category: tfe.CategoryTerraform, HCL: true},
+			},
+		},
+		{
+			name:    "tfvars with a reference",
+			format:  formatTfvars,
+			content: `region = var.region`,
+			wantErr: true,
+		},
+		{
+			name:   "dotenv",
+			format: formatDotenv,
+			content: `# comment
+export AWS_REGION=us-east-1
+
+MESSAGE="hello\nworld"
+QUOTED='single = quoted'
+EMPTY=
+`,
+			want: []NewVariable{
+				{Key: "AWS_REGION", Value: "us-east-1", Description: "from flags", Category: tfe.CategoryEnv},
+				{Key: "MESSAGE", Value: "hello\nworld", Description: "from flags", Category: tfe.CategoryEnv},
+				{Key: "QUOTED", Value: "single = quoted", Description: "from flags", Category: tfe.CategoryEnv},
+				{Key: "EMPTY", Value: "", Description: "from flags", Category: tfe.CategoryEnv},
+			},
+		},
+		{
+			name:    "dotenv without value",
+			format:  formatDotenv,
+			content: "AWS_REGION\n",
+			wantErr: true,
+		},
+		{
+			name:   "manifest",
+			format: formatManifest,
+			content: `variables:
+  - key: region
+    value: us-east-1
+    category: terraform
+    description: AWS region
+  - key: DB_PASSWORD
+    value: hunter2
+    sensitive: true
+  - key: zones
+    value: '["a"]'
+    category: terraform
+    hcl: true
+`,
+			want: []NewVariable{
+				{Key: "region", Value: "us-east-1", Description: "AWS region", Category: tfe.CategoryTerraform},
+				{Key: "DB_PASSWORD", Value: "hunter2", Description: "from flags", Category: tfe.CategoryEnv, Sensitive: true},
+				{Key: "zones", Value: `["a"]`, Description: "from flags", Category: tfe.CategoryTerraform, HCL: true},
+			},
+		},
+		{
+			name:    "manifest json",
+			format:  formatManifest,
+			content: `{"variables": [{"key": "region", "value": "us-east-1"}]}`,
+			want:    []NewVariable{{Key: "region", Value: "us-east-1", Description: "from flags", Category: tfe.CategoryEnv}},
+		},
+		{
+			name:    "manifest with an invalid category",
+			format:  formatManifest,
+			content: "variables:\n  - key: region\n    category: other\n",
+			wantErr: true,
+		},
+		{
+			name:    "manifest without key",
+			format:  formatManifest,
+			content: "variables:\n  - value: us-east-1\n",
+			wantErr: true,
+		},
+		{
+			name:    "manifest with an unknown attribute",
+			format:  formatManifest,
+			content: "variables:\n  - key: region\n    secret: true\n",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseVariableFile([]byte(test.content), test.format, defaults)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v\nwant %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTfvarsWithHCLDefault(t *testing.T) {
+	// --hcl only applies to the values of the command line, the tfvars values keep their own type
+	content := "region = \"us-east-1\"\nenabled = true\nzones = [\"a\"]\n"
+	got, err := parseVariableFile([]byte(content), formatTfvars, NewVariable{HCL: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []NewVariable{
+		{Key: "enabled", Value: "true", Category: tfe.CategoryTerraform},
+		{Key: "region", Value: "us-east-1", Category: tfe.CategoryTerraform},
+		{Key: "zones", Value: `["a"]`, Category: tfe.CategoryTerraform, HCL: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestVariableFileFormat(t *testing.T) {
+	tests := map[string]string{
+		"prod.tfvars":      formatTfvars,
+		"prod.tfvars.json": formatTfvarsJSON,
+		"vars.yaml":        formatManifest,
+		"vars.yml":         formatManifest,
+		"vars.json":        formatManifest,
+		".env":             formatDotenv,
+		"secrets.env":      formatDotenv,
+	}
+	for name, want := range tests {
+		if got := variableFileFormat(name); got != want {
+			t.Errorf("variableFileFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
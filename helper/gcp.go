@@ -0,0 +1,85 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// GCPServiceAccountKey has the fields of a service account key file the Google provider needs
+type GCPServiceAccountKey struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	ClientID     string `json:"client_id"`
+	// Credentials is the whole key on a single line
+	Credentials string `json:"-"`
+}
+
+// GetGCPServiceAccountKey reads and validates a service account key file.
+// The key is flattened to a single line because Terraform Cloud does not keep new lines in variable values
+func GetGCPServiceAccountKey(path string) (GCPServiceAccountKey, error) {
+	var key GCPServiceAccountKey
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return key, fmt.Errorf("failed to read service account key: %s", err)
+	}
+
+	if err := json.Unmarshal(content, &key); err != nil {
+		return key, fmt.Errorf("service account key %s is not valid JSON: %s", path, err)
+	}
+	if key.Type != "service_account" {
+		return key, fmt.Errorf("%s is not a service account key, its type is %q", path, key.Type)
+	}
+
+	missingFields := make([]string, 0)
+	for field, value := range map[string]string{
+		"project_id":     key.ProjectID,
+		"private_key_id": key.PrivateKeyID,
+		"private_key":    key.PrivateKey,
+		"client_email":   key.ClientEmail,
+	} {
+		if value == "" {
+			missingFields = append(missingFields, field)
+		}
+	}
+	if len(missingFields) > 0 {
+		return key, fmt.Errorf("service account key %s is missing %s", path, strings.Join(missingFields, ", "))
+	}
+	if !strings.Contains(key.PrivateKey, "PRIVATE KEY-----") {
+		return key, fmt.Errorf("the private key in %s is not a PEM key", path)
+	}
+
+	// New lines of the private key are escaped in JSON, so compacting leaves no new line at all
+	var flattened bytes.Buffer
+	if err := json.Compact(&flattened, content); err != nil {
+		return key, err
+	}
+	key.Credentials = flattened.String()
+	return key, nil
+}
+
+// Variables gets the environment variables the Google provider reads the credentials from.
+// GOOGLE_PROJECT is only added when withProject is set
+func (key GCPServiceAccountKey) Variables(withProject bool) []NewVariable {
+	variables := []NewVariable{{
+		Key:       "GOOGLE_CREDENTIALS",
+		Value:     key.Credentials,
+		Category:  tfe.CategoryEnv,
+		Sensitive: true,
+	}}
+	if withProject {
+		variables = append(variables, NewVariable{
+			Key:      "GOOGLE_PROJECT",
+			Value:    key.ProjectID,
+			Category: tfe.CategoryEnv,
+		})
+	}
+	return variables
+}
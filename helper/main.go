@@ -4,19 +4,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
 
 	"github.com/hashicorp/go-tfe"
-	"github.com/hashicorp/hcl/v2/hclsimple"
 )
 
 var client *tfe.Client
-var err error
-var ctx context.Context
+var clientOnce sync.Once
+
+// apiToken and apiHTTPClient are kept for the API calls go-tfe does not support
+var apiToken string
+var apiHTTPClient *http.Client
+var ctx = context.Background()
+var readOnly bool
+
+// ErrReadOnly is returned by every change to Terraform Cloud when the tool runs in read-only mode
+var ErrReadOnly = errors.New("tfc-helper is running in read-only mode, variables cannot be created, updated or deleted")
 
 // NewVariable is a struct that has all necessary components for variable update/creation
 type NewVariable struct {
@@ -29,18 +36,7 @@ type NewVariable struct {
 	Sensitive   bool             `jsonapi:"attr,sensitive"`
 }
 
-// Config struct has the structure of the terraformrc file
-type Config struct {
-	Credentials CredentialConfig `hcl:"credentials,block"`
-}
-
-// CredentialConfig defines the component of the credentials block in terraformrc file
-type CredentialConfig struct {
-	App   string `hcl:"app,label"`
-	Token string `hcl:"token"`
-}
-
-// GetCommandValues gets variables from the command line
+// GetCommandValues gets variables from the command line. The values are literal, see ResolveCommandValues
 func GetCommandValues(values []string) map[string]string {
 	valueToSend := make(map[string]string)
 
@@ -76,14 +72,23 @@ func GetTFValues(isTVar bool) map[string]string {
 	return valueToSend
 }
 
-// ListAllWorkspaces lists all workspaces in the organization
+// ListAllWorkspaces lists all workspaces in the organization, going through every page of the list
 func ListAllWorkspaces(organizationName string) []*tfe.Workspace {
-	workspaceList, err := client.Workspaces.List(ctx, organizationName, tfe.WorkspaceListOptions{})
-	if err != nil {
-		fmt.Println("Organization not found or incorrect! Please set the environment variable or the flag value again")
-		os.Exit(1)
+	workspaces := make([]*tfe.Workspace, 0)
+	options := tfe.WorkspaceListOptions{ListOptions: tfe.ListOptions{PageSize: 100}}
+	for {
+		workspaceList, err := getClient().Workspaces.List(ctx, organizationName, options)
+		if err != nil {
+			fmt.Println("Organization not found or incorrect! Please set the environment variable or the flag value again")
+			os.Exit(1)
+		}
+		workspaces = append(workspaces, workspaceList.Items...)
+
+		if workspaceList.Pagination == nil || workspaceList.NextPage == 0 || workspaceList.NextPage == workspaceList.CurrentPage {
+			return workspaces
+		}
+		options.PageNumber = workspaceList.NextPage
 	}
-	return workspaceList.Items
 }
 
 // GetWorkspaceID gets the workspace id in the list of workspace in the organization
@@ -103,11 +108,29 @@ func GetWorkspaceID(organizationName string, workspaceName string) string {
 
 // ListAllVariables list all variables (terraform and environment variables) in the workspace
 func ListAllVariables(workspaceID string) []*tfe.Variable {
-	variableList, err := client.Variables.List(ctx, workspaceID, tfe.VariableListOptions{})
+	variables, err := listVariables(workspaceID)
 	if err != nil {
 		log.Fatal(err)
 	}
-	return variableList.Items
+	return variables
+}
+
+// listVariables lists the variables of a workspace, going through every page of the list
+func listVariables(workspaceID string) ([]*tfe.Variable, error) {
+	variables := make([]*tfe.Variable, 0)
+	options := tfe.VariableListOptions{ListOptions: tfe.ListOptions{PageSize: 100}}
+	for {
+		variableList, err := getClient().Variables.List(ctx, workspaceID, options)
+		if err != nil {
+			return nil, err
+		}
+		variables = append(variables, variableList.Items...)
+
+		if variableList.Pagination == nil || variableList.NextPage == 0 || variableList.NextPage == variableList.CurrentPage {
+			return variables, nil
+		}
+		options.PageNumber = variableList.NextPage
+	}
 }
 
 // CheckIfVariableExistInWs checks if a variable is in a workspace or not
@@ -120,6 +143,16 @@ func CheckIfVariableExistInWs(wsVariableList []*tfe.Variable, variableName strin
 	return false
 }
 
+// findVariable finds the variable with the name in a list of variables. It returns nil when there is none
+func findVariable(wsVariableList []*tfe.Variable, variableName string) *tfe.Variable {
+	for _, variable := range wsVariableList {
+		if variable.Key == variableName {
+			return variable
+		}
+	}
+	return nil
+}
+
 // GetVar gets the variable that matches the variable name in the list of variable
 func GetVar(workspaceID string, varName string) (variable *tfe.Variable, err error) {
 	for _, variable := range ListAllVariables(workspaceID) {
@@ -132,7 +165,30 @@ func GetVar(workspaceID string, varName string) (variable *tfe.Variable, err err
 
 // CreateVariable creates a variable
 func CreateVariable(workspaceID string, newVariable NewVariable, wg *sync.WaitGroup) {
-	_, err := client.Variables.Create(ctx, workspaceID, tfe.VariableCreateOptions{
+	if err := CreateVariableE(workspaceID, newVariable); err != nil {
+		log.Fatal(err)
+	}
+	wg.Done()
+}
+
+// CreateVariableE creates a variable and returns the error instead of exiting
+func CreateVariableE(workspaceID string, newVariable NewVariable) error {
+	if err := createVariable(workspaceID, newVariable); err != nil {
+		return err
+	}
+	writeAuditRecord(workspaceID, "create", nil, &newVariable)
+	return nil
+}
+
+// createVariable sends the create request for a variable
+func createVariable(workspaceID string, newVariable NewVariable) error {
+	if readOnly {
+		return ErrReadOnly
+	}
+	if err := checkSensitiveValue(newVariable); err != nil {
+		return err
+	}
+	_, err := getClient().Variables.Create(ctx, workspaceID, tfe.VariableCreateOptions{
 		Key:         tfe.String(newVariable.Key),
 		Value:       tfe.String(newVariable.Value),
 		Description: tfe.String(newVariable.Description),
@@ -140,41 +196,103 @@ func CreateVariable(workspaceID string, newVariable NewVariable, wg *sync.WaitGr
 		HCL:         tfe.Bool(newVariable.HCL),
 		Sensitive:   tfe.Bool(newVariable.Sensitive),
 	})
+	return err
+}
 
-	if err != nil {
-		log.Fatal(err)
-	} else {
-		wg.Done()
+// checkSensitiveValue refuses to write an empty sensitive value. The API never returns sensitive values,
+// so an empty one most likely comes from reading another variable
+func checkSensitiveValue(newVariable NewVariable) error {
+	if newVariable.Sensitive && newVariable.Value == "" {
+		return fmt.Errorf("refusing to write an empty value to the sensitive variable %s, please supply its value", newVariable.Key)
 	}
+	return nil
+}
+
+// CheckRecreateValue refuses to recreate a variable with an empty value when the existing variable is sensitive,
+// whatever the new variable is. Its stored value cannot be read back, so it would be lost
+func CheckRecreateValue(existing *tfe.Variable, newVariable NewVariable) error {
+	if existing != nil && existing.Sensitive && newVariable.Value == "" {
+		return fmt.Errorf("refusing to recreate the sensitive variable %s without a value, its stored value would be lost", newVariable.Key)
+	}
+	return checkSensitiveValue(newVariable)
 }
 
 // UpdateVariable updates a variable given the variable id
 func UpdateVariable(workspaceID string, newVariable NewVariable, wg *sync.WaitGroup) {
-	_, err := client.Variables.Update(ctx, workspaceID, newVariable.ID, tfe.VariableUpdateOptions{
+	if err := UpdateVariableE(workspaceID, newVariable); err != nil {
+		log.Fatal(err)
+	}
+	wg.Done()
+}
+
+// UpdateVariableE updates a variable given the variable id and returns the error instead of exiting
+func UpdateVariableE(workspaceID string, newVariable NewVariable) error {
+	oldVariable := readVariableForAudit(workspaceID, newVariable.ID)
+	if err := updateVariable(workspaceID, newVariable); err != nil {
+		return err
+	}
+	writeAuditRecord(workspaceID, "update", oldVariable, &newVariable)
+	return nil
+}
+
+// updateVariable sends the update request for a variable
+func updateVariable(workspaceID string, newVariable NewVariable) error {
+	if readOnly {
+		return ErrReadOnly
+	}
+	options := tfe.VariableUpdateOptions{
 		Value:       tfe.String(newVariable.Value),
 		Description: tfe.String(newVariable.Description),
 		HCL:         tfe.Bool(newVariable.HCL),
 		Sensitive:   tfe.Bool(newVariable.Sensitive),
-	})
-
-	if err != nil {
-		log.Fatal(err)
-	} else {
-		wg.Done()
 	}
+	// An empty sensitive value keeps the stored value instead of overwriting it with a blank
+	if keepsSensitiveValue(newVariable) {
+		options.Value = nil
+	}
+	_, err := getClient().Variables.Update(ctx, workspaceID, newVariable.ID, options)
+	return err
+}
+
+// keepsSensitiveValue checks whether an update keeps the stored value of a sensitive variable
+func keepsSensitiveValue(newVariable NewVariable) bool {
+	return newVariable.Sensitive && newVariable.Value == ""
 }
 
 // DeleteVar deletes a single variable
 func DeleteVar(workspaceID string, variableID string, wg *sync.WaitGroup) {
 	defer wg.Done()
-	message := fmt.Sprintf("Error deleting variable id %s. Please try again!", variableID)
-	if err := client.Variables.Delete(ctx, workspaceID, variableID); err != nil {
-		fmt.Println(message)
+	if err := DeleteVarE(workspaceID, variableID); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// DeleteVarE deletes a single variable and returns the error instead of printing it
+func DeleteVarE(workspaceID string, variableID string) error {
+	oldVariable := readVariableForAudit(workspaceID, variableID)
+	if err := deleteVariable(workspaceID, variableID); err != nil {
+		return err
 	}
+	writeAuditRecord(workspaceID, "delete", oldVariable, nil)
+	return nil
+}
+
+// deleteVariable sends the delete request for a variable
+func deleteVariable(workspaceID string, variableID string) error {
+	if readOnly {
+		return ErrReadOnly
+	}
+	if err := getClient().Variables.Delete(ctx, workspaceID, variableID); err != nil {
+		return fmt.Errorf("error deleting variable id %s, please try again: %s", variableID, err)
+	}
+	return nil
 }
 
 // DeleteVariables deletes a variable or all variables
 func DeleteVariables(workspaceID string, variableID string, all bool) {
+	if readOnly {
+		log.Fatal(ErrReadOnly)
+	}
 	var wg sync.WaitGroup
 
 	if all {
@@ -182,6 +300,7 @@ func DeleteVariables(workspaceID string, variableID string, all bool) {
 			wg.Add(1)
 			go DeleteVar(workspaceID, variable.ID, &wg)
 		}
+		wg.Wait()
 		return
 	}
 
@@ -192,46 +311,106 @@ func DeleteVariables(workspaceID string, variableID string, all bool) {
 
 // RecreateVariable deletes a variable and create it again
 func RecreateVariable(workspaceID string, variable NewVariable, wg *sync.WaitGroup) {
-	DeleteVariables(workspaceID, variable.ID, false)
-	CreateVariable(workspaceID, variable, wg)
-}
-
-func init() {
-	// Get token from terraformrc from Linux
-	// TODO: implement this in Windows
-	homeDir, _ := os.UserHomeDir()
-	var terraformCloudToken string
-	content, fileReadingError := ioutil.ReadFile(fmt.Sprintf("%s/.terraformrc", homeDir))
-	if fileReadingError != nil {
-		// Read from environment variable TF_CLOUD_TOKEN
-		terraformCloudToken = os.Getenv("TF_CLOUD_TOKEN")
-	} else {
-		// Structure of terraformrc file
-		var terraformrcConfig Config
-		decodeErr := hclsimple.Decode(
-			"somefile.hcl", []byte(content),
-			nil, &terraformrcConfig,
-		)
-		if decodeErr != nil {
-			log.Fatalf("Failed to load configuration: %s", decodeErr)
-		}
-		terraformCloudToken = terraformrcConfig.Credentials.Token
-	}
-
-	if terraformCloudToken == "" {
-		fmt.Println("Token not available!")
-		return
+	if err := RecreateVariableE(workspaceID, variable); err != nil {
+		log.Fatal(err)
 	}
+	wg.Done()
+}
 
-	tFconfig := &tfe.Config{
-		Token: terraformCloudToken,
+// RecreateVariableE deletes a variable and create it again, returning the error instead of exiting
+func RecreateVariableE(workspaceID string, variable NewVariable) error {
+	if readOnly {
+		return ErrReadOnly
+	}
+	// Check before deleting, otherwise the variable would be lost
+	oldVariable, err := getClient().Variables.Read(ctx, workspaceID, variable.ID)
+	if err != nil {
+		return err
+	}
+	if err := CheckRecreateValue(oldVariable, variable); err != nil {
+		return err
+	}
+	if err := deleteVariable(workspaceID, variable.ID); err != nil {
+		return err
+	}
+	if err := createVariable(workspaceID, variable); err != nil {
+		// The variable is gone, so the delete is recorded on its own
+		writeAuditRecord(workspaceID, "delete", oldVariable, nil)
+		return err
 	}
+	writeAuditRecord(workspaceID, "recreate", oldVariable, &variable)
+	return nil
+}
+
+// PushVariables creates the variables that do not exist in the workspace and updates the ones that do.
+// Variables changing category or going from sensitive to non-sensitive are recreated
+func PushVariables(workspaceID string, variables []NewVariable) {
+	variablesInWs := ListAllVariables(workspaceID)
 
-	client, err = tfe.NewClient(tFconfig)
+	var wg sync.WaitGroup
+	for _, variable := range variables {
+		wg.Add(1)
+		existingVariable := findVariable(variablesInWs, variable.Key)
+		switch {
+		case existingVariable == nil:
+			go CreateVariable(workspaceID, variable, &wg)
+		case existingVariable.Category != variable.Category || (existingVariable.Sensitive && !variable.Sensitive):
+			variable.ID = existingVariable.ID
+			go RecreateVariable(workspaceID, variable, &wg)
+		default:
+			variable.ID = existingVariable.ID
+			go UpdateVariable(workspaceID, variable, &wg)
+		}
+	}
+	wg.Wait()
+}
+
+// QueueRun queues a plan and apply run in the workspace and returns the run ID
+func QueueRun(workspaceID string, message string) (string, error) {
+	if readOnly {
+		return "", ErrReadOnly
+	}
+	run, err := getClient().Runs.Create(ctx, tfe.RunCreateOptions{
+		Workspace: &tfe.Workspace{ID: workspaceID},
+		Message:   tfe.String(message),
+	})
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
+	return run.ID, nil
+}
+
+// SetReadOnly sets whether the tool refuses every change to Terraform Cloud
+func SetReadOnly(enabled bool) {
+	readOnly = enabled
+}
 
-	// Create a context
-	ctx = context.Background()
+// getClient creates the Terraform Cloud client the first time a command needs the API,
+// so commands that never talk to Terraform Cloud do not require a token
+func getClient() *tfe.Client {
+	clientOnce.Do(func() {
+		terraformCloudToken := getToken()
+		if terraformCloudToken == "" {
+			fmt.Println("Token not available! Please configure the terraformrc file or set the TF_CLOUD_TOKEN environment variable")
+			os.Exit(1)
+		}
+
+		httpClient, err := newHTTPClient(transportConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		tFconfig := &tfe.Config{
+			Token:      terraformCloudToken,
+			HTTPClient: httpClient,
+		}
+
+		client, err = tfe.NewClient(tFconfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		apiToken = terraformCloudToken
+		apiHTTPClient = httpClient
+	})
+	return client
 }
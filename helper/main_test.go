@@ -0,0 +1,168 @@
+package helper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// newTestAPI points the Terraform Cloud client to a stub server for the duration of a test.
+// The requests the handler gets are recorded as "METHOD path", with the path relative to /api/v2/
+func newTestAPI(t *testing.T, handler http.HandlerFunc) func() []string {
+	var mutex sync.Mutex
+	requests := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, tfe.DefaultBasePath)
+		if path == tfe.PingEndpoint {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mutex.Lock()
+		requests = append(requests, r.Method+" "+path)
+		mutex.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	setEnv(t, "TFE_ADDRESS", server.URL)
+	testClient, err := tfe.NewClient(&tfe.Config{Address: server.URL, Token: "test-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The client is created once, the stub takes its place without going through the token lookup
+	clientOnce.Do(func() {})
+	previousClient, previousToken, previousHTTPClient := client, apiToken, apiHTTPClient
+	client, apiToken, apiHTTPClient = testClient, "test-token", server.Client()
+	t.Cleanup(func() { client, apiToken, apiHTTPClient = previousClient, previousToken, previousHTTPClient })
+
+	return func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string{}, requests...)
+	}
+}
+
+// writeTestVariable answers with a JSON:API document of a workspace variable
+func writeTestVariable(w http.ResponseWriter, status int, id string, key string, sensitive bool) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"data":{"id":%q,"type":"vars","attributes":{"key":%q,"value":"","category":"env","sensitive":%t}}}`, id, key, sensitive)
+}
+
+func TestRecreateVariableE(t *testing.T) {
+	tests := []struct {
+		name              string
+		existingSensitive bool
+		variable          NewVariable
+		wantErr           bool
+		want              []string
+	}{
+		{
+			name:              "sensitive to non-sensitive without value",
+			existingSensitive: true,
+			variable:          NewVariable{ID: "var-1", Key: "DB_PASSWORD", Category: tfe.CategoryEnv},
+			wantErr:           true,
+			want:              []string{"GET workspaces/ws-1/vars/var-1"},
+		},
+		{
+			name:              "sensitive without value",
+			existingSensitive: true,
+			variable:          NewVariable{ID: "var-1", Key: "DB_PASSWORD", Category: tfe.CategoryTerraform, Sensitive: true},
+			wantErr:           true,
+			want:              []string{"GET workspaces/ws-1/vars/var-1"},
+		},
+		{
+			name:              "sensitive to non-sensitive with a value",
+			existingSensitive: true,
+			variable:          NewVariable{ID: "var-1", Key: "DB_PASSWORD", Value: "hunter2", Category: tfe.CategoryEnv},
+			want:              []string{"GET workspaces/ws-1/vars/var-1", "DELETE workspaces/ws-1/vars/var-1", "POST workspaces/ws-1/vars"},
+		},
+		{
+			name:     "plain variable without value",
+			variable: NewVariable{ID: "var-1", Key: "REGION", Category: tfe.CategoryTerraform},
+			want:     []string{"GET workspaces/ws-1/vars/var-1", "DELETE workspaces/ws-1/vars/var-1", "POST workspaces/ws-1/vars"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case "GET":
+					writeTestVariable(w, http.StatusOK, "var-1", test.variable.Key, test.existingSensitive)
+				case "DELETE":
+					w.WriteHeader(http.StatusNoContent)
+				case "POST":
+					writeTestVariable(w, http.StatusCreated, "var-2", test.variable.Key, test.variable.Sensitive)
+				}
+			})
+
+			err := RecreateVariableE("ws-1", test.variable)
+			if test.wantErr != (err != nil) {
+				t.Fatalf("got error %v, want error %t", err, test.wantErr)
+			}
+			// The variable must never be deleted when it cannot be created again
+			if got := requests(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got requests %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	SetReadOnly(true)
+	defer SetReadOnly(false)
+	requests := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	variable := NewVariable{ID: "var-1", Key: "REGION", Value: "us-east-1", Category: tfe.CategoryTerraform}
+	existing := &tfe.Variable{ID: "var-1", Key: "REGION", Category: tfe.CategoryTerraform}
+	set := VariableSet{ID: "varset-1", Name: "shared", Organization: "acme"}
+	workspace := &tfe.Workspace{ID: "ws-1", Name: "app-prod"}
+	mutators := map[string]func() error{
+		"CreateVariableE":   func() error { return CreateVariableE("ws-1", variable) },
+		"UpdateVariableE":   func() error { return UpdateVariableE("ws-1", variable) },
+		"DeleteVarE":        func() error { return DeleteVarE("ws-1", "var-1") },
+		"RecreateVariableE": func() error { return RecreateVariableE("ws-1", variable) },
+		"QueueRun": func() error {
+			_, err := QueueRun("ws-1", "test")
+			return err
+		},
+		"CreateVariableSet": func() error {
+			_, err := CreateVariableSet("acme", "shared", "", false)
+			return err
+		},
+		"SetVariableSetGlobal":      func() error { return SetVariableSetGlobal(set, true) },
+		"AttachVariableSet":         func() error { return AttachVariableSet(set, workspace) },
+		"DetachVariableSet":         func() error { return DetachVariableSet(set, workspace) },
+		"CreateVariableSetVariable": func() error { return CreateVariableSetVariable(set, variable) },
+		"UpdateVariableSetVariable": func() error { return UpdateVariableSetVariable(set, existing, variable) },
+		"DeleteVariableSetVariable": func() error { return DeleteVariableSetVariable(set, existing) },
+		"ApplyVariableSetChange": func() error {
+			return ApplyVariableSetChange(set, Change{Action: ActionRecreate, Existing: existing, Variable: variable})
+		},
+	}
+
+	for name, mutate := range mutators {
+		t.Run(name, func(t *testing.T) {
+			if err := mutate(); err != ErrReadOnly {
+				t.Errorf("got error %v, want ErrReadOnly", err)
+			}
+		})
+	}
+	// Nothing is sent to Terraform Cloud, not even a read
+	if got := requests(); len(got) != 0 {
+		t.Errorf("got requests %v, want none", got)
+	}
+}
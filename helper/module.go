@@ -0,0 +1,338 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"gopkg.in/yaml.v2"
+)
+
+// ModuleVariable is a variable block declared in a Terraform module
+type ModuleVariable struct {
+	Name        string
+	Description string
+	// Type is cty.DynamicPseudoType when the type is any, not set or cannot be read
+	Type      cty.Type
+	TypeName  string
+	Required  bool
+	Sensitive bool
+	// Default is the source text of the default value, empty when there is none
+	Default  string
+	Filename string
+	Line     int
+}
+
+// ModuleIssue is a difference between the variables a module declares and the variables of a workspace
+type ModuleIssue struct {
+	Key     string
+	Problem string
+}
+
+// moduleFileSchema only reads the variable blocks of a module, the other blocks are ignored
+var moduleFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "variable", LabelNames: []string{"name"}}},
+}
+
+// moduleVariableSchema reads the attributes of a variable block that matter for the workspace variables
+var moduleVariableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "type"},
+		{Name: "default"},
+		{Name: "description"},
+		{Name: "sensitive"},
+	},
+}
+
+// LoadModuleVariables reads the variable blocks of the .tf and .tf.json files of a module directory
+func LoadModuleVariables(dir string) ([]ModuleVariable, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", dir, err)
+	}
+
+	parser := hclparse.NewParser()
+	variables := make([]ModuleVariable, 0)
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, ".") || (!strings.HasSuffix(name, ".tf") && !strings.HasSuffix(name, ".tf.json")) {
+			continue
+		}
+
+		var hclFile *hcl.File
+		var diags hcl.Diagnostics
+		if strings.HasSuffix(name, ".tf.json") {
+			hclFile, diags = parser.ParseJSONFile(filepath.Join(dir, name))
+		} else {
+			hclFile, diags = parser.ParseHCLFile(filepath.Join(dir, name))
+		}
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		content, _, diags := hclFile.Body.PartialContent(moduleFileSchema)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		for _, block := range content.Blocks {
+			variable, err := readModuleVariable(block, hclFile.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			variables = append(variables, variable)
+		}
+	}
+
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+	return variables, nil
+}
+
+// readModuleVariable reads a variable block. A variable without default is required
+func readModuleVariable(block *hcl.Block, source []byte) (ModuleVariable, error) {
+	variable := ModuleVariable{
+		Name:     block.Labels[0],
+		Type:     cty.DynamicPseudoType,
+		TypeName: "any",
+		Required: true,
+		Filename: block.DefRange.Filename,
+		Line:     block.DefRange.Start.Line,
+	}
+
+	content, _, diags := block.Body.PartialContent(moduleVariableSchema)
+	if diags.HasErrors() {
+		return variable, diags
+	}
+
+	if attribute, found := content.Attributes["type"]; found {
+		variable.TypeName = strings.TrimSpace(string(attribute.Expr.Range().SliceBytes(source)))
+		// Newer type constraints such as optional attributes are not known to this version of HCL
+		if constraint, diags := typeexpr.TypeConstraint(attribute.Expr); !diags.HasErrors() {
+			variable.Type = constraint
+			variable.TypeName = typeexpr.TypeString(constraint)
+		}
+	}
+	if attribute, found := content.Attributes["default"]; found {
+		variable.Required = false
+		variable.Default = strings.TrimSpace(string(attribute.Expr.Range().SliceBytes(source)))
+	}
+	if attribute, found := content.Attributes["description"]; found {
+		if value, diags := attribute.Expr.Value(nil); !diags.HasErrors() && value.Type() == cty.String && !value.IsNull() {
+			variable.Description = value.AsString()
+		}
+	}
+	if attribute, found := content.Attributes["sensitive"]; found {
+		if value, diags := attribute.Expr.Value(nil); !diags.HasErrors() && value.Type() == cty.Bool && !value.IsNull() {
+			variable.Sensitive = value.True()
+		}
+	}
+	return variable, nil
+}
+
+// CheckModuleVariables compares the variables a module declares with the variables a run of a workspace sees. It reports
+// the required variables nothing sets, the terraform variables of the workspace no variable block declares and the
+// values whose HCL flag does not fit the declared type. A TF_VAR_ environment variable also sets a variable.
+// Variables of variable sets are shared with other modules, so they are only reported when their type does not fit
+func CheckModuleVariables(declared []ModuleVariable, variables []EffectiveVariable) []ModuleIssue {
+	issues := make([]ModuleIssue, 0)
+	declaredByName := make(map[string]ModuleVariable)
+	for _, variable := range declared {
+		declaredByName[variable.Name] = variable
+	}
+
+	set := make(map[string]bool)
+	for _, effective := range variables {
+		variable := effective.Variable
+		switch {
+		case variable.Category == tfe.CategoryEnv && strings.HasPrefix(variable.Key, "TF_VAR_"):
+			set[strings.TrimPrefix(variable.Key, "TF_VAR_")] = true
+		case variable.Category == tfe.CategoryTerraform:
+			set[variable.Key] = true
+			moduleVariable, found := declaredByName[variable.Key]
+			if !found {
+				if effective.Source == SourceWorkspace {
+					issues = append(issues, ModuleIssue{Key: variable.Key, Problem: "is not declared by any variable block"})
+				}
+				continue
+			}
+			if problem := checkHCLFlag(moduleVariable, variable); problem != "" {
+				if effective.Source != SourceWorkspace {
+					problem = fmt.Sprintf("%s (set in %s)", problem, effective.Source)
+				}
+				issues = append(issues, ModuleIssue{Key: variable.Key, Problem: problem})
+			}
+		}
+	}
+
+	for _, variable := range declared {
+		if variable.Required && !set[variable.Name] {
+			issues = append(issues, ModuleIssue{Key: variable.Name, Problem: fmt.Sprintf("is required by %s:%d but not set", filepath.Base(variable.Filename), variable.Line)})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Key < issues[j].Key })
+	return issues
+}
+
+// checkHCLFlag checks that the HCL flag of a workspace variable fits the declared type, and that
+// the value can be converted to it. Sensitive values cannot be read, so only their flag is checked
+func checkHCLFlag(declared ModuleVariable, variable *tfe.Variable) string {
+	declaredType := declared.Type
+	if declaredType == cty.DynamicPseudoType {
+		return ""
+	}
+
+	complex := !declaredType.IsPrimitiveType()
+	if complex && !variable.HCL {
+		return fmt.Sprintf("is declared as %s, so its value has to be in HCL but the HCL flag is not set", declared.TypeName)
+	}
+	if variable.Sensitive || !variable.HCL {
+		return ""
+	}
+
+	expr, diags := hclsyntax.ParseExpression([]byte(variable.Value), variable.Key, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return "has the HCL flag but its value is not valid HCL"
+	}
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return "has the HCL flag but its value is not a literal HCL value"
+	}
+	if _, err := convert.Convert(value, declaredType); err != nil {
+		return fmt.Sprintf("has a value that is not a %s: %s", declared.TypeName, err)
+	}
+	return ""
+}
+
+// SkeletonValue gets the value a variable starts with in a skeleton file, and whether it is in HCL.
+// It is the default of the variable, or an empty value of the declared type for a required variable.
+// Strings are returned without quotes unless the value is in HCL
+func (variable ModuleVariable) SkeletonValue() (string, bool) {
+	source := variable.skeletonSource()
+
+	expr, diags := hclsyntax.ParseExpression([]byte(source), variable.Name, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return source, true
+	}
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return source, true
+	}
+	switch {
+	case value.Type() == cty.String && !value.IsNull() && (variable.Type.IsPrimitiveType() || variable.Type == cty.DynamicPseudoType):
+		return value.AsString(), false
+	case variable.Type.IsPrimitiveType() || value.Type().IsPrimitiveType():
+		return source, variable.Type == cty.DynamicPseudoType && value.Type() != cty.String
+	}
+	return source, true
+}
+
+// skeletonSource gets the default of a variable in HCL, or an empty value of the declared type when it has none
+func (variable ModuleVariable) skeletonSource() string {
+	if variable.Default != "" && variable.Default != "null" {
+		return variable.Default
+	}
+	// A type this version of HCL cannot read is still a collection or an object when its name says so
+	if variable.Type == cty.DynamicPseudoType {
+		for prefix, placeholder := range map[string]string{"object(": "{}", "map(": "{}", "list(": "[]", "set(": "[]", "tuple(": "[]"} {
+			if strings.HasPrefix(variable.TypeName, prefix) {
+				return placeholder
+			}
+		}
+	}
+	return placeholderValue(variable.Type)
+}
+
+// placeholderValue gets the empty value of a type in HCL
+func placeholderValue(valueType cty.Type) string {
+	switch {
+	case valueType == cty.Number:
+		return "0"
+	case valueType == cty.Bool:
+		return "false"
+	case valueType.IsListType(), valueType.IsSetType(), valueType.IsTupleType():
+		return "[]"
+	case valueType.IsMapType(), valueType.IsObjectType():
+		return "{}"
+	}
+	return `""`
+}
+
+// skeletonComments gets the comments written above a variable in a skeleton file
+func skeletonComments(variable ModuleVariable) []string {
+	comments := make([]string, 0)
+	if variable.Description != "" {
+		for _, line := range strings.Split(strings.TrimSpace(variable.Description), "\n") {
+			comments = append(comments, strings.TrimSpace(line))
+		}
+	}
+	comments = append(comments, "type: "+variable.TypeName)
+	if variable.Required {
+		comments = append(comments, "required, please set a value")
+	} else {
+		comments = append(comments, "default: "+strings.Join(strings.Fields(variable.Default), " "))
+	}
+	if variable.Sensitive {
+		comments = append(comments, "sensitive")
+	}
+	return comments
+}
+
+// ManifestSkeleton writes the variables of a module as a YAML manifest that update --var-file reads.
+// The types and whether a value is required are written as comments
+func ManifestSkeleton(variables []ModuleVariable) ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString("variables:\n")
+	for i, variable := range variables {
+		value, isHCL := variable.SkeletonValue()
+		category := string(tfe.CategoryTerraform)
+		manifestVariable := ManifestVariable{Key: variable.Name, Value: value, Category: &category, HCL: &isHCL}
+		if variable.Description != "" {
+			manifestVariable.Description = &variable.Description
+		}
+		if variable.Sensitive {
+			manifestVariable.Sensitive = &variable.Sensitive
+		}
+		entry, err := yaml.Marshal([]ManifestVariable{manifestVariable})
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		for _, comment := range skeletonComments(variable) {
+			fmt.Fprintf(&out, "  # %s\n", comment)
+		}
+		for _, line := range strings.SplitAfter(strings.TrimRight(string(entry), "\n"), "\n") {
+			fmt.Fprintf(&out, "  %s", line)
+		}
+		out.WriteString("\n")
+	}
+	return out.Bytes(), nil
+}
+
+// TfvarsSkeleton writes the variables of a module as a tfvars file, with the types and
+// whether a value is required as comments
+func TfvarsSkeleton(variables []ModuleVariable) []byte {
+	var out bytes.Buffer
+	for i, variable := range variables {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		for _, comment := range skeletonComments(variable) {
+			fmt.Fprintf(&out, "# %s\n", comment)
+		}
+		fmt.Fprintf(&out, "%s = %s\n", variable.Name, variable.skeletonSource())
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,301 @@
+package helper
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+const testModule = `variable "region" {
+  description = "AWS region"
+  type        = string
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
+
+variable "instance_count" {
+  type = number
+}
+
+variable "settings" {
+  type    = object({ size = optional(string) })
+  default = null
+}
+
+variable "db_password" {
+  sensitive = true
+}
+
+variable "name" {
+  type    = string
+  default = "web"
+}
+
+resource "null_resource" "ignored" {}
+`
+
+// writeTestModule writes a module directory with the files
+func writeTestModule(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadModuleVariables(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"variables.tf":      testModule,
+		"extra.tf.json":     `{"variable": {"zones": {"type": "list(string)", "default": ["a"]}}}`,
+		"README.md":         `variable "not_read" {}`,
+		".hidden.tf":        `variable "hidden" {}`,
+		"terraform.tfstate": `{}`,
+	})
+
+	variables, err := LoadModuleVariables(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for _, variable := range variables {
+		required := "optional"
+		if variable.Required {
+			required = "required"
+		}
+		if variable.Sensitive {
+			required += ", sensitive"
+		}
+		got[variable.Name] = variable.TypeName + " " + required
+	}
+	want := map[string]string{
+		"db_password":    "any required, sensitive",
+		"instance_count": "number required",
+		"name":           "string optional",
+		"region":         "string required",
+		// optional attributes are not known to this version of HCL, the type is kept as written
+		"settings": "object({ size = optional(string) }) optional",
+		"tags":     "map(string) optional",
+		"zones":    "list(string) optional",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+	if variables[0].Name != "db_password" || variables[len(variables)-1].Name != "zones" {
+		t.Error("the variables are not sorted by name")
+	}
+}
+
+func TestLoadModuleVariablesRejectsInvalidFiles(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{"main.tf": `variable "a" {`})
+	if _, err := LoadModuleVariables(dir); err == nil {
+		t.Fatal("an invalid module was read")
+	}
+}
+
+func TestCheckModuleVariables(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{"variables.tf": testModule})
+	declared, err := LoadModuleVariables(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workspace := func(variable tfe.Variable) EffectiveVariable {
+		return EffectiveVariable{Variable: &variable, Source: SourceWorkspace}
+	}
+	fromSet := func(variable tfe.Variable) EffectiveVariable {
+		return EffectiveVariable{Variable: &variable, Source: "shared"}
+	}
+	terraform := tfe.CategoryTerraform
+
+	tests := []struct {
+		name      string
+		variables []EffectiveVariable
+		want      []ModuleIssue
+	}{
+		{
+			name: "everything set",
+			variables: []EffectiveVariable{
+				workspace(tfe.Variable{Key: "region", Value: "us-east-1", Category: terraform}),
+				workspace(tfe.Variable{Key: "instance_count", Value: "3", Category: terraform}),
+				workspace(tfe.Variable{Key: "tags", Value: `{team = "a"}`, Category: terraform, HCL: true}),
+				workspace(tfe.Variable{Key: "TF_VAR_db_password", Category: tfe.CategoryEnv, Sensitive: true}),
+			},
+			want: []ModuleIssue{},
+		},
+		{
+			name: "required variables missing",
+			variables: []EffectiveVariable{
+				workspace(tfe.Variable{Key: "AWS_REGION", Value: "us-east-1", Category: tfe.CategoryEnv}),
+			},
+			want: []ModuleIssue{
+				{Key: "db_password", Problem: "is required by variables.tf:20 but not set"},
+				{Key: "instance_count", Problem: "is required by variables.tf:11 but not set"},
+				{Key: "region", Problem: "is required by variables.tf:1 but not set"},
+			},
+		},
+		{
+			name: "variable sets count and are not undeclared",
+			variables: []EffectiveVariable{
+				fromSet(tfe.Variable{Key: "region", Value: "us-east-1", Category: terraform}),
+				fromSet(tfe.Variable{Key: "instance_count", Value: "3", Category: terraform}),
+				fromSet(tfe.Variable{Key: "db_password", Category: terraform, Sensitive: true}),
+				fromSet(tfe.Variable{Key: "other_module", Value: "x", Category: terraform}),
+			},
+			want: []ModuleIssue{},
+		},
+		{
+			name: "undeclared workspace variable",
+			variables: []EffectiveVariable{
+				workspace(tfe.Variable{Key: "region", Value: "us-east-1", Category: terraform}),
+				workspace(tfe.Variable{Key: "instance_count", Value: "3", Category: terraform}),
+				workspace(tfe.Variable{Key: "db_password", Category: terraform, Sensitive: true}),
+				workspace(tfe.Variable{Key: "regoin", Value: "us-east-1", Category: terraform}),
+			},
+			want: []ModuleIssue{{Key: "regoin", Problem: "is not declared by any variable block"}},
+		},
+		{
+			name: "values that do not fit the type",
+			variables: []EffectiveVariable{
+				workspace(tfe.Variable{Key: "region", Value: "us-east-1", Category: terraform}),
+				workspace(tfe.Variable{Key: "db_password", Category: terraform, Sensitive: true}),
+				workspace(tfe.Variable{Key: "tags", Value: `{team = "a"}`, Category: terraform}),
+				workspace(tfe.Variable{Key: "name", Value: "web app", Category: terraform, HCL: true}),
+				fromSet(tfe.Variable{Key: "instance_count", Value: `"three"`, Category: terraform, HCL: true}),
+			},
+			want: []ModuleIssue{
+				{Key: "instance_count", Problem: "has a value that is not a number: a number is required (set in shared)"},
+				{Key: "name", Problem: "has the HCL flag but its value is not valid HCL"},
+				{Key: "tags", Problem: "is declared as map(string), so its value has to be in HCL but the HCL flag is not set"},
+			},
+		},
+		{
+			name: "quoted HCL string and sensitive HCL value",
+			variables: []EffectiveVariable{
+				workspace(tfe.Variable{Key: "region", Value: `"us-east-1"`, Category: terraform, HCL: true}),
+				workspace(tfe.Variable{Key: "instance_count", Category: terraform, HCL: true, Sensitive: true}),
+				workspace(tfe.Variable{Key: "db_password", Category: terraform, Sensitive: true}),
+			},
+			want: []ModuleIssue{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := CheckModuleVariables(declared, test.variables); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v\nwant %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSkeletonValue(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"variables.tf": testModule + `
+variable "untyped_string" {
+  default = "x"
+}
+
+variable "untyped_number" {
+  default = 3
+}
+
+variable "untyped_list" {
+  default = ["a"]
+}
+
+variable "enabled" {
+  type = bool
+}
+
+variable "zones" {
+  type = list(string)
+}
+`,
+	})
+	variables, err := LoadModuleVariables(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type skeleton struct {
+		Value string
+		HCL   bool
+	}
+	want := map[string]skeleton{
+		"db_password":    {Value: "", HCL: false},
+		"enabled":        {Value: "false", HCL: false},
+		"instance_count": {Value: "0", HCL: false},
+		"name":           {Value: "web", HCL: false},
+		"region":         {Value: "", HCL: false},
+		"settings":       {Value: "{}", HCL: true},
+		"tags":           {Value: "{}", HCL: true},
+		"untyped_list":   {Value: `["a"]`, HCL: true},
+		"untyped_number": {Value: "3", HCL: true},
+		"untyped_string": {Value: "x", HCL: false},
+		"zones":          {Value: "[]", HCL: true},
+	}
+	got := make(map[string]skeleton)
+	for _, variable := range variables {
+		value, isHCL := variable.SkeletonValue()
+		got[variable.Name] = skeleton{Value: value, HCL: isHCL}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestSkeletonsCanBeLoaded(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{"variables.tf": testModule})
+	declared, err := LoadModuleVariables(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := ManifestSkeleton(declared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string][]byte{
+		formatManifest: manifest,
+		formatTfvars:   TfvarsSkeleton(declared),
+	}
+
+	for format, content := range tests {
+		t.Run(format, func(t *testing.T) {
+			variables, err := parseVariableFile(content, format, NewVariable{Category: tfe.CategoryEnv})
+			if err != nil {
+				t.Fatalf("%s\n%s", err, content)
+			}
+			got := make(map[string]NewVariable)
+			for _, variable := range variables {
+				got[variable.Key] = variable
+			}
+			if len(got) != len(declared) {
+				t.Fatalf("got %d variables, want %d", len(got), len(declared))
+			}
+			if tags := got["tags"]; tags.Category != tfe.CategoryTerraform || !tags.HCL || tags.Value != "{}" {
+				t.Errorf("unexpected tags %+v", tags)
+			}
+			if name := got["name"]; name.Value != "web" || name.HCL {
+				t.Errorf("unexpected name %+v", name)
+			}
+			if format == formatManifest {
+				if region := got["region"]; region.Description != "AWS region" {
+					t.Errorf("unexpected region %+v", region)
+				}
+				if password := got["db_password"]; !password.Sensitive {
+					t.Errorf("unexpected db_password %+v", password)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package helper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/hashicorp/go-tfe"
+	"gopkg.in/yaml.v2"
+)
+
+// PromotionOverrides is the structure of an overrides file, with the keys that are never promoted
+// and the variables that differ in the target environment
+type PromotionOverrides struct {
+	Exclude   []string            `yaml:"exclude"`
+	Variables []PromotionOverride `yaml:"variables"`
+}
+
+// PromotionOverride changes a promoted variable, or adds it when the source does not have it.
+// Attributes that are not set keep the ones of the source
+type PromotionOverride struct {
+	Key         string  `yaml:"key"`
+	Value       *string `yaml:"value,omitempty"`
+	Description *string `yaml:"description,omitempty"`
+	Category    *string `yaml:"category,omitempty"`
+	HCL         *bool   `yaml:"hcl,omitempty"`
+	Sensitive   *bool   `yaml:"sensitive,omitempty"`
+}
+
+// LoadPromotionOverrides reads an overrides file in YAML or JSON. A variable cannot be both overridden and excluded.
+// Values read from a secret store with vault:path#field are resolved and always sensitive
+func LoadPromotionOverrides(filePath string) (PromotionOverrides, error) {
+	var overrides PromotionOverrides
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return overrides, fmt.Errorf("failed to read %s: %s", filePath, err)
+	}
+	if err := yaml.UnmarshalStrict(content, &overrides); err != nil {
+		return overrides, fmt.Errorf("failed to parse %s: %s", filePath, err)
+	}
+
+	for _, pattern := range overrides.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return overrides, fmt.Errorf("invalid exclude pattern %q in %s: %s", pattern, filePath, err)
+		}
+	}
+	for i := range overrides.Variables {
+		override := &overrides.Variables[i]
+		if override.Key == "" {
+			return overrides, fmt.Errorf("variable #%d of %s has no key", i+1, filePath)
+		}
+		if overrides.Excludes(override.Key) {
+			return overrides, fmt.Errorf("variable %s of %s is also excluded, remove it from the exclude list or from the variables", override.Key, filePath)
+		}
+		if override.Category != nil {
+			category := tfe.CategoryType(*override.Category)
+			if category != tfe.CategoryEnv && category != tfe.CategoryTerraform {
+				return overrides, fmt.Errorf("variable %s of %s has an invalid category %q, it should be env or terraform", override.Key, filePath, category)
+			}
+		}
+		if override.Value != nil && IsSensitiveReference(*override.Value) {
+			value, err := ResolveValue(*override.Value)
+			if err != nil {
+				return overrides, fmt.Errorf("cannot get the value of %s: %s", override.Key, err)
+			}
+			sensitive := true
+			override.Value = &value
+			override.Sensitive = &sensitive
+		}
+	}
+	return overrides, nil
+}
+
+// Excludes checks whether a key matches one of the exclude patterns
+func (overrides PromotionOverrides) Excludes(key string) bool {
+	return matchesAnyPattern(overrides.Exclude, key)
+}
+
+// Apply removes the excluded variables, changes the overridden ones and adds the overrides the variables do not have.
+// New variables take the category, description and flags of defaults when the override does not set them
+func (overrides PromotionOverrides) Apply(variables []NewVariable, defaults NewVariable) []NewVariable {
+	promoted := make([]NewVariable, 0, len(variables))
+	for _, variable := range variables {
+		if !overrides.Excludes(variable.Key) {
+			promoted = append(promoted, variable)
+		}
+	}
+
+	for _, override := range overrides.Variables {
+		found := false
+		for i := range promoted {
+			if promoted[i].Key == override.Key {
+				promoted[i] = override.apply(promoted[i])
+				found = true
+			}
+		}
+		if !found {
+			variable := defaults
+			variable.Key = override.Key
+			promoted = append(promoted, override.apply(variable))
+		}
+	}
+	return promoted
+}
+
+// apply sets the attributes of the override on the variable
+func (override PromotionOverride) apply(variable NewVariable) NewVariable {
+	if override.Value != nil {
+		variable.Value = *override.Value
+	}
+	if override.Description != nil {
+		variable.Description = *override.Description
+	}
+	if override.Category != nil {
+		variable.Category = tfe.CategoryType(*override.Category)
+	}
+	if override.HCL != nil {
+		variable.HCL = *override.HCL
+	}
+	if override.Sensitive != nil {
+		variable.Sensitive = *override.Sensitive
+	}
+	return variable
+}
@@ -0,0 +1,76 @@
+package helper
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestPromotionOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "staging.yaml")
+	content := `exclude:
+  - DEV_*
+variables:
+  - key: instance_count
+    value: "3"
+  - key: LOG_LEVEL
+    category: env
+    sensitive: true
+  - key: STAGING_ONLY
+    value: "yes"
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	overrides, err := LoadPromotionOverrides(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !overrides.Excludes("DEV_DEBUG") || overrides.Excludes("instance_count") {
+		t.Error("the exclude patterns are not applied")
+	}
+
+	variables := []NewVariable{
+		{Key: "instance_count", Value: "1", Description: "count", Category: tfe.CategoryTerraform},
+		{Key: "DEV_DEBUG", Value: "true", Category: tfe.CategoryEnv},
+		{Key: "LOG_LEVEL", Value: "debug", Category: tfe.CategoryTerraform},
+		{Key: "REGION", Value: "us-east-1", Category: tfe.CategoryEnv},
+	}
+	defaults := NewVariable{Description: "promoted", Category: tfe.CategoryEnv}
+
+	got := overrides.Apply(variables, defaults)
+	want := []NewVariable{
+		{Key: "instance_count", Value: "3", Description: "count", Category: tfe.CategoryTerraform},
+		{Key: "LOG_LEVEL", Value: "debug", Category: tfe.CategoryEnv, Sensitive: true},
+		{Key: "REGION", Value: "us-east-1", Category: tfe.CategoryEnv},
+		{Key: "STAGING_ONLY", Value: "yes", Description: "promoted", Category: tfe.CategoryEnv},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestLoadPromotionOverridesRejectsInvalidFiles(t *testing.T) {
+	tests := map[string]string{
+		"no key":           "variables:\n  - value: a\n",
+		"invalid category": "variables:\n  - key: a\n    category: other\n",
+		"invalid pattern":  "exclude:\n  - \"[\"\n",
+		"unknown field":    "variables:\n  - key: a\n    type: string\n",
+		"excluded key":     "exclude:\n  - DEV_*\nvariables:\n  - key: DEV_DEBUG\n    value: \"true\"\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "overrides.yaml")
+			if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadPromotionOverrides(path); err == nil {
+				t.Fatal("the overrides were accepted")
+			}
+		})
+	}
+}
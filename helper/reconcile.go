@@ -0,0 +1,105 @@
+package helper
+
+import (
+	"sort"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// Actions of the changes needed to bring a workspace to the wanted variables
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionRecreate = "recreate"
+	ActionDelete   = "delete"
+	ActionSkip     = "skip"
+)
+
+// Change is a change needed to bring a workspace variable to its wanted state
+type Change struct {
+	Action string
+	// Variable is the wanted state of the variable, its ID is the one of the existing variable
+	Variable NewVariable
+	// Existing is the variable in the workspace, nil when it does not exist yet
+	Existing *tfe.Variable
+	// Reason explains why a change is skipped
+	Reason string
+}
+
+// PlanChanges compares the variables of a workspace with the wanted variables and lists the changes needed.
+// Variables that are already as wanted have no change. With prune, the variables that are not wanted are deleted.
+// Sensitive values cannot be compared, so a wanted sensitive value is always written and an empty one keeps the stored value
+func PlanChanges(existingVariables []*tfe.Variable, wantedVariables []NewVariable, prune bool) []Change {
+	changes := make([]Change, 0)
+	wantedKeys := make(map[string]bool)
+
+	for _, wanted := range wantedVariables {
+		wantedKeys[wanted.Key] = true
+		existing := findVariable(existingVariables, wanted.Key)
+		missingValue := wanted.Sensitive && wanted.Value == ""
+
+		if existing == nil {
+			if missingValue {
+				changes = append(changes, Change{Action: ActionSkip, Variable: wanted, Reason: "no value for the sensitive variable"})
+			} else {
+				changes = append(changes, Change{Action: ActionCreate, Variable: wanted})
+			}
+			continue
+		}
+
+		wanted.ID = existing.ID
+		change := Change{Variable: wanted, Existing: existing}
+		switch {
+		case existing.Category != wanted.Category || (existing.Sensitive && !wanted.Sensitive):
+			change.Action = ActionRecreate
+			// The stored value of a sensitive variable is lost when it is recreated without a value
+			if CheckRecreateValue(existing, wanted) != nil {
+				change.Action = ActionSkip
+				change.Reason = "no value to recreate the sensitive variable"
+			}
+		case needsUpdate(existing, wanted):
+			change.Action = ActionUpdate
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	if prune {
+		for _, existing := range existingVariables {
+			if !wantedKeys[existing.Key] {
+				changes = append(changes, Change{Action: ActionDelete, Variable: NewVariable{ID: existing.ID, Key: existing.Key, Category: existing.Category}, Existing: existing})
+			}
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Variable.Key < changes[j].Variable.Key })
+	return changes
+}
+
+// needsUpdate checks whether an existing variable differs from its wanted state in a way an update can fix
+func needsUpdate(existing *tfe.Variable, wanted NewVariable) bool {
+	if existing.Description != wanted.Description || existing.HCL != wanted.HCL || existing.Sensitive != wanted.Sensitive {
+		return true
+	}
+	if existing.Sensitive {
+		// The stored value is unknown, so any supplied value is written
+		return wanted.Value != ""
+	}
+	return existing.Value != wanted.Value
+}
+
+// ApplyChange applies a single change to the workspace. Skipped changes do nothing
+func ApplyChange(workspaceID string, change Change) error {
+	switch change.Action {
+	case ActionCreate:
+		return CreateVariableE(workspaceID, change.Variable)
+	case ActionUpdate:
+		return UpdateVariableE(workspaceID, change.Variable)
+	case ActionRecreate:
+		return RecreateVariableE(workspaceID, change.Variable)
+	case ActionDelete:
+		return DeleteVarE(workspaceID, change.Variable.ID)
+	}
+	return nil
+}
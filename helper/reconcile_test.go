@@ -0,0 +1,133 @@
+package helper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestPlanChanges(t *testing.T) {
+	existing := []*tfe.Variable{
+		{ID: "var-1", Key: "REGION", Value: "us-east-1", Category: tfe.CategoryEnv},
+		{ID: "var-2", Key: "DB_PASSWORD", Category: tfe.CategoryEnv, Sensitive: true},
+		{ID: "var-3", Key: "zones", Value: `["a"]`, Category: tfe.CategoryTerraform, HCL: true},
+		{ID: "var-4", Key: "EXTRA", Value: "x", Category: tfe.CategoryEnv},
+	}
+
+	tests := []struct {
+		name   string
+		wanted []NewVariable
+		prune  bool
+		want   map[string]string
+	}{
+		{
+			name: "unchanged",
+			wanted: []NewVariable{
+				{Key: "REGION", Value: "us-east-1", Category: tfe.CategoryEnv},
+				{Key: "zones", Value: `["a"]`, Category: tfe.CategoryTerraform, HCL: true},
+			},
+			want: map[string]string{},
+		},
+		{
+			name: "create and update",
+			wanted: []NewVariable{
+				{Key: "REGION", Value: "eu-west-1", Category: tfe.CategoryEnv},
+				{Key: "NEW", Value: "v", Category: tfe.CategoryEnv},
+				{Key: "zones", Value: `["a"]`, Description: "zones", Category: tfe.CategoryTerraform, HCL: true},
+			},
+			want: map[string]string{"REGION": ActionUpdate, "NEW": ActionCreate, "zones": ActionUpdate},
+		},
+		{
+			name:   "extra variables are kept without prune",
+			wanted: []NewVariable{{Key: "REGION", Value: "us-east-1", Category: tfe.CategoryEnv}},
+			want:   map[string]string{},
+		},
+		{
+			name:   "extra variables are deleted with prune",
+			wanted: []NewVariable{{Key: "REGION", Value: "us-east-1", Category: tfe.CategoryEnv}},
+			prune:  true,
+			want:   map[string]string{"DB_PASSWORD": ActionDelete, "zones": ActionDelete, "EXTRA": ActionDelete},
+		},
+		{
+			name: "category change recreates",
+			wanted: []NewVariable{
+				{Key: "REGION", Value: "us-east-1", Category: tfe.CategoryTerraform},
+			},
+			want: map[string]string{"REGION": ActionRecreate},
+		},
+		{
+			name: "sensitive to plain recreates with a value",
+			wanted: []NewVariable{
+				{Key: "DB_PASSWORD", Value: "hunter2", Category: tfe.CategoryEnv},
+			},
+			want: map[string]string{"DB_PASSWORD": ActionRecreate},
+		},
+		{
+			name: "sensitive category change without value is skipped",
+			wanted: []NewVariable{
+				{Key: "DB_PASSWORD", Category: tfe.CategoryTerraform, Sensitive: true},
+			},
+			want: map[string]string{"DB_PASSWORD": ActionSkip},
+		},
+		{
+			name: "sensitive without value keeps the stored value",
+			wanted: []NewVariable{
+				{Key: "DB_PASSWORD", Category: tfe.CategoryEnv, Sensitive: true},
+			},
+			want: map[string]string{},
+		},
+		{
+			name: "sensitive to plain without value is skipped",
+			wanted: []NewVariable{
+				{Key: "DB_PASSWORD", Category: tfe.CategoryEnv},
+			},
+			want: map[string]string{"DB_PASSWORD": ActionSkip},
+		},
+		{
+			name: "sensitive with value is always written",
+			wanted: []NewVariable{
+				{Key: "DB_PASSWORD", Value: "hunter2", Category: tfe.CategoryEnv, Sensitive: true},
+			},
+			want: map[string]string{"DB_PASSWORD": ActionUpdate},
+		},
+		{
+			name: "plain to sensitive is an update",
+			wanted: []NewVariable{
+				{Key: "REGION", Value: "us-east-1", Category: tfe.CategoryEnv, Sensitive: true},
+			},
+			want: map[string]string{"REGION": ActionUpdate},
+		},
+		{
+			name: "new sensitive without value is skipped",
+			wanted: []NewVariable{
+				{Key: "API_TOKEN", Category: tfe.CategoryEnv, Sensitive: true},
+			},
+			want: map[string]string{"API_TOKEN": ActionSkip},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes := PlanChanges(existing, test.wanted, test.prune)
+			got := make(map[string]string)
+			for _, change := range changes {
+				got[change.Variable.Key] = change.Action
+				if change.Existing != nil && change.Variable.ID != change.Existing.ID {
+					t.Errorf("%s: the change has ID %q, want the existing %q", change.Variable.Key, change.Variable.ID, change.Existing.ID)
+				}
+				if change.Action == ActionSkip && change.Reason == "" {
+					t.Errorf("%s: skipped without reason", change.Variable.Key)
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+			for i := 1; i < len(changes); i++ {
+				if changes[i-1].Variable.Key > changes[i].Variable.Key {
+					t.Errorf("changes are not sorted by key: %s before %s", changes[i-1].Variable.Key, changes[i].Variable.Key)
+				}
+			}
+		})
+	}
+}
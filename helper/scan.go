@@ -0,0 +1,117 @@
+package helper
+
+import (
+	"path"
+	"regexp"
+	"sync"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// DefaultConcurrency is the number of workspaces read at the same time when scanning an organization
+const DefaultConcurrency = 8
+
+// WorkspaceVariables has the variables of a workspace, or the error met while listing them
+type WorkspaceVariables struct {
+	Workspace *tfe.Workspace
+	Variables []*tfe.Variable
+	Err       error
+}
+
+// ListVariablesInWorkspaces lists the variables of every workspace with at most concurrency requests at a time.
+// The results are in the same order as the workspaces
+func ListVariablesInWorkspaces(workspaces []*tfe.Workspace, concurrency int) []WorkspaceVariables {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]WorkspaceVariables, len(workspaces))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, workspace := range workspaces {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, workspace *tfe.Workspace) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			variables, err := listVariables(workspace.ID)
+			results[i] = WorkspaceVariables{Workspace: workspace, Variables: variables, Err: err}
+		}(i, workspace)
+	}
+	wg.Wait()
+	return results
+}
+
+// RotationTarget is a variable to rotate with the workspace it belongs to
+type RotationTarget struct {
+	Workspace *tfe.Workspace
+	Variable  NewVariable
+}
+
+// FindRotationTargets returns the variable with the key of every listed workspace, set to the new value.
+// The category, description, HCL flag and sensitivity are kept, and forceSensitive makes every variable sensitive.
+// The workspaces that could not be listed are skipped
+func FindRotationTargets(results []WorkspaceVariables, key string, value string, forceSensitive bool) []RotationTarget {
+	targets := make([]RotationTarget, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		for _, variable := range result.Variables {
+			if variable.Key != key {
+				continue
+			}
+			targets = append(targets, RotationTarget{
+				Workspace: result.Workspace,
+				Variable: NewVariable{
+					ID:          variable.ID,
+					Key:         variable.Key,
+					Value:       value,
+					Description: variable.Description,
+					Category:    variable.Category,
+					HCL:         variable.HCL,
+					Sensitive:   variable.Sensitive || forceSensitive,
+				},
+			})
+		}
+	}
+	return targets
+}
+
+// VariableMatch is a variable found by SearchVariables
+type VariableMatch struct {
+	Workspace   string `json:"workspace"`
+	WorkspaceID string `json:"workspace_id"`
+	Key         string `json:"key"`
+	Category    string `json:"category"`
+	Sensitive   bool   `json:"sensitive"`
+}
+
+// SearchVariables returns the variables whose key matches the glob pattern and, when valueRegex is set,
+// whose value matches it. The values of sensitive variables cannot be read, so they never match valueRegex.
+// The workspaces that could not be listed are skipped
+func SearchVariables(results []WorkspaceVariables, keyPattern string, valueRegex *regexp.Regexp) []VariableMatch {
+	matches := make([]VariableMatch, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		for _, variable := range result.Variables {
+			if matched, _ := path.Match(keyPattern, variable.Key); !matched {
+				continue
+			}
+			if valueRegex != nil && (variable.Sensitive || !valueRegex.MatchString(variable.Value)) {
+				continue
+			}
+			matches = append(matches, VariableMatch{
+				Workspace:   result.Workspace.Name,
+				WorkspaceID: result.Workspace.ID,
+				Key:         variable.Key,
+				Category:    string(variable.Category),
+				Sensitive:   variable.Sensitive,
+			})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,188 @@
+package helper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"gopkg.in/yaml.v2"
+)
+
+// Types a schema can give to a variable
+const (
+	SchemaTypeString = "string"
+	SchemaTypeNumber = "number"
+	SchemaTypeBool   = "bool"
+	SchemaTypeList   = "list"
+	SchemaTypeMap    = "map"
+)
+
+// Schema is the structure of a schema file declaring what the variables must look like
+type Schema struct {
+	Variables []SchemaVariable `yaml:"variables"`
+}
+
+// SchemaVariable declares the rules of a single variable. Rules that are not set are not checked
+type SchemaVariable struct {
+	Key       string   `yaml:"key"`
+	Type      string   `yaml:"type,omitempty"`
+	Pattern   string   `yaml:"pattern,omitempty"`
+	Allowed   []string `yaml:"allowed,omitempty"`
+	Category  string   `yaml:"category,omitempty"`
+	Required  bool     `yaml:"required,omitempty"`
+	Sensitive bool     `yaml:"sensitive,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Violation is a variable that does not follow its schema
+type Violation struct {
+	Key     string
+	Message string
+}
+
+func (violation Violation) String() string {
+	return fmt.Sprintf("%s: %s", violation.Key, violation.Message)
+}
+
+// LoadSchema reads a schema file in YAML or JSON and checks that its rules are valid
+func LoadSchema(path string) (Schema, error) {
+	var schema Schema
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return schema, fmt.Errorf("failed to read %s: %s", path, err)
+	}
+	if err := yaml.UnmarshalStrict(content, &schema); err != nil {
+		return schema, fmt.Errorf("failed to parse %s: %s", path, err)
+	}
+
+	for i := range schema.Variables {
+		rule := &schema.Variables[i]
+		if rule.Key == "" {
+			return schema, fmt.Errorf("variable #%d of %s has no key", i+1, path)
+		}
+		switch rule.Type {
+		case "", SchemaTypeString, SchemaTypeNumber, SchemaTypeBool, SchemaTypeList, SchemaTypeMap:
+		default:
+			return schema, fmt.Errorf("variable %s of %s has an invalid type %q, it should be string, number, bool, list or map", rule.Key, path, rule.Type)
+		}
+		if rule.Category != "" && tfe.CategoryType(rule.Category) != tfe.CategoryEnv && tfe.CategoryType(rule.Category) != tfe.CategoryTerraform {
+			return schema, fmt.Errorf("variable %s of %s has an invalid category %q, it should be env or terraform", rule.Key, path, rule.Category)
+		}
+		if rule.Pattern != "" {
+			// The whole value has to match the pattern
+			if rule.pattern, err = regexp.Compile("^(?:" + rule.Pattern + ")$"); err != nil {
+				return schema, fmt.Errorf("variable %s of %s has an invalid pattern: %s", rule.Key, path, err)
+			}
+		}
+	}
+	return schema, nil
+}
+
+// Validate checks the variables against the schema and returns every violation found.
+// existingKeys are the keys already in the workspace, which count for the required variables.
+// The value of a sensitive variable is not checked when it is empty since the stored value is kept
+func (schema Schema) Validate(variables []NewVariable, existingKeys []string) []Violation {
+	violations := make([]Violation, 0)
+	present := make(map[string]bool)
+	for _, key := range existingKeys {
+		present[key] = true
+	}
+
+	for _, variable := range variables {
+		present[variable.Key] = true
+		for _, rule := range schema.Variables {
+			if rule.Key != variable.Key {
+				continue
+			}
+			for _, message := range rule.check(variable) {
+				violations = append(violations, Violation{Key: variable.Key, Message: message})
+			}
+		}
+	}
+
+	for _, rule := range schema.Variables {
+		if rule.Required && !present[rule.Key] {
+			violations = append(violations, Violation{Key: rule.Key, Message: "is required but not set"})
+		}
+	}
+	return violations
+}
+
+// check lists what is wrong with a variable according to its rule
+func (rule SchemaVariable) check(variable NewVariable) []string {
+	messages := make([]string, 0)
+	if rule.Category != "" && variable.Category != tfe.CategoryType(rule.Category) {
+		messages = append(messages, fmt.Sprintf("must be a %s variable, not %s", rule.Category, variable.Category))
+	}
+	if rule.Sensitive && !variable.Sensitive {
+		messages = append(messages, "must be sensitive")
+	}
+	if variable.Sensitive && variable.Value == "" {
+		return messages
+	}
+
+	// Values of sensitive variables are never shown in the messages
+	shown := strconv.Quote(variable.Value)
+	if variable.Sensitive {
+		shown = "the value"
+	}
+	if rule.Type != "" {
+		if err := checkValueType(variable.Value, rule.Type); err != nil {
+			messages = append(messages, fmt.Sprintf("%s is not a %s: %s", shown, rule.Type, err))
+		}
+	}
+	if rule.pattern != nil && !rule.pattern.MatchString(variable.Value) {
+		messages = append(messages, fmt.Sprintf("%s does not match %s", shown, rule.Pattern))
+	}
+	if len(rule.Allowed) > 0 && !containsString(rule.Allowed, variable.Value) {
+		messages = append(messages, fmt.Sprintf("%s is not one of %s", shown, strings.Join(rule.Allowed, ", ")))
+	}
+	return messages
+}
+
+// checkValueType checks that a value can be read as the type. Lists and maps are read as HCL
+func checkValueType(value string, valueType string) error {
+	switch valueType {
+	case SchemaTypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("invalid number")
+		}
+	case SchemaTypeBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("it should be true or false")
+		}
+	case SchemaTypeList, SchemaTypeMap:
+		expr, diags := hclsyntax.ParseExpression([]byte(value), "value", hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return fmt.Errorf("invalid HCL")
+		}
+		parsed, diags := expr.Value(nil)
+		if diags.HasErrors() {
+			return fmt.Errorf("invalid HCL")
+		}
+		parsedType := parsed.Type()
+		if valueType == SchemaTypeList && !parsedType.IsTupleType() && !parsedType.IsListType() {
+			return fmt.Errorf("it should be a list such as [\"a\", \"b\"]")
+		}
+		if valueType == SchemaTypeMap && !parsedType.IsObjectType() && !parsedType.IsMapType() {
+			return fmt.Errorf("it should be a map such as {a = \"b\"}")
+		}
+	}
+	return nil
+}
+
+// containsString checks whether a list has the value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
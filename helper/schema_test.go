@@ -0,0 +1,166 @@
+package helper
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+const testSchema = `variables:
+  - key: instance_count
+    type: number
+    category: terraform
+    required: true
+  - key: environment
+    allowed: [dev, staging, prod]
+  - key: bucket
+    pattern: "[a-z0-9-]+"
+  - key: zones
+    type: list
+  - key: tags
+    type: map
+  - key: enabled
+    type: bool
+  - key: DB_PASSWORD
+    sensitive: true
+    pattern: ".{8,}"
+`
+
+// loadTestSchema writes the schema to a file and loads it
+func loadTestSchema(t *testing.T, content string) (Schema, error) {
+	path := filepath.Join(t.TempDir(), "schema.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadSchema(path)
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := loadTestSchema(t, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	terraform := func(key string, value string) NewVariable {
+		return NewVariable{Key: key, Value: value, Category: tfe.CategoryTerraform}
+	}
+
+	tests := []struct {
+		name         string
+		variables    []NewVariable
+		existingKeys []string
+		want         []string
+	}{
+		{
+			name: "valid",
+			variables: []NewVariable{
+				terraform("instance_count", "3"),
+				terraform("environment", "prod"),
+				terraform("bucket", "my-bucket-01"),
+				terraform("zones", `["a", "b"]`),
+				terraform("tags", `{team = "payments"}`),
+				terraform("enabled", "true"),
+				{Key: "DB_PASSWORD", Value: "long enough", Category: tfe.CategoryEnv, Sensitive: true},
+			},
+		},
+		{
+			name:         "required variable already in the workspace",
+			existingKeys: []string{"instance_count"},
+		},
+		{
+			name: "missing required variable",
+			want: []string{"instance_count: is required but not set"},
+		},
+		{
+			name:      "wrong type and category",
+			variables: []NewVariable{{Key: "instance_count", Value: "three", Category: tfe.CategoryEnv}},
+			want: []string{
+				"instance_count: must be a terraform variable, not env",
+				`instance_count: "three" is not a number: invalid number`,
+			},
+		},
+		{
+			name:         "value not allowed",
+			variables:    []NewVariable{terraform("environment", "qa")},
+			existingKeys: []string{"instance_count"},
+			want:         []string{`environment: "qa" is not one of dev, staging, prod`},
+		},
+		{
+			name:         "pattern matches the whole value",
+			variables:    []NewVariable{terraform("bucket", "My_Bucket")},
+			existingKeys: []string{"instance_count"},
+			want:         []string{`bucket: "My_Bucket" does not match [a-z0-9-]+`},
+		},
+		{
+			name: "collections",
+			variables: []NewVariable{
+				terraform("zones", `{a = "b"}`),
+				terraform("tags", `["a"]`),
+				terraform("enabled", "yes"),
+			},
+			existingKeys: []string{"instance_count"},
+			want: []string{
+				`zones: "{a = \"b\"}" is not a list: it should be a list such as ["a", "b"]`,
+				`tags: "[\"a\"]" is not a map: it should be a map such as {a = "b"}`,
+				`enabled: "yes" is not a bool: it should be true or false`,
+			},
+		},
+		{
+			name:         "sensitive values are never shown",
+			variables:    []NewVariable{{Key: "DB_PASSWORD", Value: "short", Category: tfe.CategoryEnv}},
+			existingKeys: []string{"instance_count"},
+			want: []string{
+				"DB_PASSWORD: must be sensitive",
+				`DB_PASSWORD: "short" does not match .{8,}`,
+			},
+		},
+		{
+			name:         "sensitive value hidden",
+			variables:    []NewVariable{{Key: "DB_PASSWORD", Value: "short", Category: tfe.CategoryEnv, Sensitive: true}},
+			existingKeys: []string{"instance_count"},
+			want:         []string{"DB_PASSWORD: the value does not match .{8,}"},
+		},
+		{
+			name:         "kept sensitive value is not checked",
+			variables:    []NewVariable{{Key: "DB_PASSWORD", Category: tfe.CategoryEnv, Sensitive: true}},
+			existingKeys: []string{"instance_count"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, violation := range schema.Validate(test.variables, test.existingKeys) {
+				got = append(got, violation.String())
+			}
+			if test.want == nil {
+				test.want = []string{}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q\nwant %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadSchemaRejectsInvalidRules(t *testing.T) {
+	tests := map[string]string{
+		"no key":           "variables:\n  - type: string\n",
+		"invalid type":     "variables:\n  - key: a\n    type: object\n",
+		"invalid category": "variables:\n  - key: a\n    category: other\n",
+		"invalid pattern":  "variables:\n  - key: a\n    pattern: \"[\"\n",
+		"unknown field":    "variables:\n  - key: a\n    min: 1\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadTestSchema(t, content); err == nil {
+				t.Fatal("the schema was accepted")
+			} else if name != "unknown field" && !strings.Contains(err.Error(), "schema.yaml") {
+				t.Errorf("the error %q does not name the file", err)
+			}
+		})
+	}
+}
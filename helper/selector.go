@@ -0,0 +1,129 @@
+package helper
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// WorkspaceSelector selects workspaces of an organization by name pattern, tag or a list of names.
+// A workspace has to match one of the patterns, have all the tags and be in the list, for the criteria that are set
+type WorkspaceSelector struct {
+	Patterns []string
+	Tags     []string
+	File     string
+}
+
+// IsSet checks whether any criteria of the selector is set
+func (selector WorkspaceSelector) IsSet() bool {
+	return len(selector.Patterns) > 0 || len(selector.Tags) > 0 || selector.File != ""
+}
+
+// SelectWorkspaces lists the workspaces of the organization matching the selector, sorted by name like the API does
+func SelectWorkspaces(orgName string, selector WorkspaceSelector) ([]*tfe.Workspace, error) {
+	for _, pattern := range selector.Patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid workspace pattern %q: %s", pattern, err)
+		}
+	}
+
+	var names map[string]bool
+	if selector.File != "" {
+		fileNames, err := readWorkspaceFile(selector.File)
+		if err != nil {
+			return nil, err
+		}
+		names = make(map[string]bool)
+		for _, name := range fileNames {
+			names[name] = true
+		}
+	}
+
+	var taggedIDs map[string]bool
+	if len(selector.Tags) > 0 {
+		ids, err := listTaggedWorkspaceIDs(orgName, selector.Tags)
+		if err != nil {
+			return nil, err
+		}
+		taggedIDs = make(map[string]bool)
+		for _, id := range ids {
+			taggedIDs[id] = true
+		}
+	}
+
+	selected := make([]*tfe.Workspace, 0)
+	found := make(map[string]bool)
+	for _, workspace := range ListAllWorkspaces(orgName) {
+		found[workspace.Name] = true
+		if names != nil && !names[workspace.Name] {
+			continue
+		}
+		if taggedIDs != nil && !taggedIDs[workspace.ID] {
+			continue
+		}
+		if len(selector.Patterns) > 0 && !matchesAnyPattern(selector.Patterns, workspace.Name) {
+			continue
+		}
+		selected = append(selected, workspace)
+	}
+
+	// A name that does not exist is most likely a typo in the list
+	for name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("workspace %s of %s does not exist in organization %s", name, selector.File, orgName)
+		}
+	}
+	return selected, nil
+}
+
+// matchesAnyPattern checks whether the name matches one of the glob patterns
+func matchesAnyPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// readWorkspaceFile reads a file with one workspace name per line. Empty lines and comments are ignored
+func readWorkspaceFile(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", filePath, err)
+	}
+	defer file.Close()
+
+	names := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, scanner.Err()
+}
+
+// listTaggedWorkspaceIDs lists the IDs of the workspaces having all the tags.
+// go-tfe does not support tags yet, so the API is called directly
+func listTaggedWorkspaceIDs(orgName string, tags []string) ([]string, error) {
+	query := url.Values{}
+	query.Set("search[tags]", strings.Join(tags, ","))
+	resources, err := apiList(fmt.Sprintf("organizations/%s/workspaces", url.PathEscape(orgName)), query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the workspaces tagged %s: %s", strings.Join(tags, ", "), err)
+	}
+
+	ids := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		ids = append(ids, resource.ID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,58 @@
+package helper
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchesAnyPattern(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		name     string
+		want     bool
+	}{
+		{patterns: []string{"app-*-prod"}, name: "app-payments-prod", want: true},
+		{patterns: []string{"app-*-prod"}, name: "app-payments-staging", want: false},
+		{patterns: []string{"app-*-prod", "*-staging"}, name: "app-payments-staging", want: true},
+		{patterns: []string{"app-?"}, name: "app-1", want: true},
+		{patterns: []string{"APP-*"}, name: "app-1", want: false},
+		{patterns: nil, name: "app-1", want: false},
+	}
+	for _, test := range tests {
+		if got := matchesAnyPattern(test.patterns, test.name); got != test.want {
+			t.Errorf("matchesAnyPattern(%q, %q) = %v, want %v", test.patterns, test.name, got, test.want)
+		}
+	}
+}
+
+func TestReadWorkspaceFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspaces.txt")
+	if err := ioutil.WriteFile(path, []byte("# payments\napp-payments-prod\n\n  app-orders-prod  \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := readWorkspaceFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"app-payments-prod", "app-orders-prod"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %q, want %q", names, want)
+	}
+
+	if _, err := readWorkspaceFile(path + ".missing"); err == nil {
+		t.Error("a missing file was read")
+	}
+}
+
+func TestWorkspaceSelectorIsSet(t *testing.T) {
+	if (WorkspaceSelector{}).IsSet() {
+		t.Error("an empty selector is set")
+	}
+	for _, selector := range []WorkspaceSelector{{Patterns: []string{"a*"}}, {Tags: []string{"team:a"}}, {File: "ws.txt"}} {
+		if !selector.IsSet() {
+			t.Errorf("%+v is not set", selector)
+		}
+	}
+}
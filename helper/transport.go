@@ -0,0 +1,78 @@
+package helper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/hashicorp/go-cleanhttp"
+)
+
+// TransportConfig has the TLS and proxy settings used to reach a Terraform Cloud or Terraform Enterprise instance
+type TransportConfig struct {
+	CACert             string
+	ClientCert         string
+	ClientKey          string
+	Proxy              string
+	InsecureSkipVerify bool
+}
+
+var transportConfig TransportConfig
+
+// SetTransportConfig sets the TLS and proxy settings. It has to be called before the first API call
+func SetTransportConfig(config TransportConfig) {
+	transportConfig = config
+}
+
+// newHTTPClient creates the HTTP client given to the Terraform Cloud client based on the transport config
+func newHTTPClient(config TransportConfig) (*http.Client, error) {
+	httpClient := cleanhttp.DefaultPooledClient()
+	transport := httpClient.Transport.(*http.Transport)
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// Only meant for labs, never for production instances
+		InsecureSkipVerify: config.InsecureSkipVerify, // #nosec G402
+	}
+
+	if config.CACert != "" {
+		pem, err := ioutil.ReadFile(config.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %s", err)
+		}
+		// Keep trusting the system roots so public endpoints still work
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in CA bundle %s", config.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCert != "" || config.ClientKey != "" {
+		if config.ClientCert == "" || config.ClientKey == "" {
+			return nil, errors.New("both the client certificate and the client key are required")
+		}
+		certificate, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if config.Proxy != "" {
+		proxyURL, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %s", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return httpClient, nil
+}
@@ -0,0 +1,198 @@
+package helper
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCertificate is a certificate and its key, both PEM encoded
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	certPEM     []byte
+	keyPEM      []byte
+}
+
+// newTestCertificate creates a certificate signed by parent, or a self-signed CA when parent is nil
+func newTestCertificate(t *testing.T, name string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.certificate, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCertificate{
+		certificate: certificate,
+		key:         key,
+		certPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:      pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// writeTestFile writes content to a file of a temporary directory and returns its path
+func writeTestFile(t *testing.T, name string, content []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewHTTPClientTLS(t *testing.T) {
+	ca := newTestCertificate(t, "test CA", nil)
+	serverCertificate := newTestCertificate(t, "server", ca)
+	clientCertificate := newTestCertificate(t, "client", ca)
+
+	// The server only accepts clients with a certificate signed by the test CA
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serverKeyPair, err := tls.X509KeyPair(serverCertificate.certPEM, serverCertificate.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.certificate)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverKeyPair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := writeTestFile(t, "ca.pem", ca.certPEM)
+	clientCertFile := writeTestFile(t, "client.pem", clientCertificate.certPEM)
+	clientKeyFile := writeTestFile(t, "client-key.pem", clientCertificate.keyPEM)
+
+	tests := []struct {
+		name          string
+		config        TransportConfig
+		wantConfigErr bool
+		wantErr       bool
+	}{
+		{
+			name:   "CA bundle and client certificate",
+			config: TransportConfig{CACert: caFile, ClientCert: clientCertFile, ClientKey: clientKeyFile},
+		},
+		{
+			name:    "unknown CA",
+			config:  TransportConfig{ClientCert: clientCertFile, ClientKey: clientKeyFile},
+			wantErr: true,
+		},
+		{
+			name:    "no client certificate",
+			config:  TransportConfig{CACert: caFile},
+			wantErr: true,
+		},
+		{
+			name:   "insecure",
+			config: TransportConfig{ClientCert: clientCertFile, ClientKey: clientKeyFile, InsecureSkipVerify: true},
+		},
+		{
+			name:          "client certificate without key",
+			config:        TransportConfig{CACert: caFile, ClientCert: clientCertFile},
+			wantConfigErr: true,
+		},
+		{
+			name:          "CA bundle without certificate",
+			config:        TransportConfig{CACert: clientKeyFile},
+			wantConfigErr: true,
+		},
+		{
+			name:          "missing CA bundle",
+			config:        TransportConfig{CACert: filepath.Join(t.TempDir(), "missing.pem")},
+			wantConfigErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpClient, err := newHTTPClient(test.config)
+			if test.wantConfigErr != (err != nil) {
+				t.Fatalf("got error %v, want error %t", err, test.wantConfigErr)
+			}
+			if err != nil {
+				return
+			}
+
+			response, err := httpClient.Get(server.URL)
+			if test.wantErr != (err != nil) {
+				t.Fatalf("got error %v, want error %t", err, test.wantErr)
+			}
+			if err == nil {
+				response.Body.Close()
+				if response.StatusCode != http.StatusNoContent {
+					t.Errorf("got status %d, want %d", response.StatusCode, http.StatusNoContent)
+				}
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientProxy(t *testing.T) {
+	// The proxy answers in place of the target, which is never reached
+	proxied := ""
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	httpClient, err := newHTTPClient(TransportConfig{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := httpClient.Get("http://tfe.example.com/api/v2/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if want := "http://tfe.example.com/api/v2/ping"; proxied != want {
+		t.Errorf("got proxied request %q, want %q", proxied, want)
+	}
+
+	if _, err := newHTTPClient(TransportConfig{Proxy: "://no-scheme"}); err == nil {
+		t.Error("got no error for an invalid proxy URL")
+	}
+}
@@ -0,0 +1,156 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Prefixes of the values that reference another source instead of being the value itself
+const (
+	fileValuePrefix = "@"
+	envValuePrefix  = "env:"
+	stdinValue      = "-"
+)
+
+var stdinMutex sync.Mutex
+var stdinUsed bool
+
+// ResolveValue gets the value a reference points to.
+// "@path" is the content of the file, "env:NAME" the value of the environment variable,
+// "vault:path#field" a field of a Vault KV secret and "-" the standard input.
+// Any other value is returned as it is
+func ResolveValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, vaultValuePrefix):
+		return readVaultValue(strings.TrimPrefix(value, vaultValuePrefix))
+	case value == stdinValue:
+		return readStdinValue()
+	case strings.HasPrefix(value, fileValuePrefix):
+		path := strings.TrimPrefix(value, fileValuePrefix)
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read value from file: %s", err)
+		}
+		return string(content), nil
+	case strings.HasPrefix(value, envValuePrefix):
+		name := strings.TrimPrefix(value, envValuePrefix)
+		envValue, found := os.LookupEnv(name)
+		if !found {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return envValue, nil
+	}
+	return value, nil
+}
+
+// readStdinValue reads the whole standard input. The standard input can only be used for one value
+func readStdinValue() (string, error) {
+	stdinMutex.Lock()
+	defer stdinMutex.Unlock()
+	if stdinUsed {
+		return "", errors.New("only one value can be read from the standard input")
+	}
+	stdinUsed = true
+
+	content, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read value from the standard input: %s", err)
+	}
+	// Drop the new line added by echo or by pressing enter
+	return strings.TrimSuffix(string(content), "\n"), nil
+}
+
+// ReadsStandardInput checks whether one of the KEY=value pairs of the command line reads its value from the standard input
+func ReadsStandardInput(values []string) bool {
+	for _, value := range GetCommandValues(values) {
+		if value == stdinValue {
+			return true
+		}
+	}
+	return false
+}
+
+// StandardInputUsed checks whether a value was already read from the standard input
+func StandardInputUsed() bool {
+	stdinMutex.Lock()
+	defer stdinMutex.Unlock()
+	return stdinUsed
+}
+
+// ResolveCommandValues gets variables from the command line like GetCommandValues and resolves the
+// values that reference a file with @path, an environment variable with env:NAME, a Vault secret or the standard input
+func ResolveCommandValues(values []string) (map[string]string, error) {
+	resolved := GetCommandValues(values)
+	for key, value := range resolved {
+		value, err := ResolveValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get the value of %s: %s", key, err)
+		}
+		resolved[key] = value
+	}
+	return resolved, nil
+}
+
+// IsSensitiveReference checks whether a value references a secret store.
+// Variables with such values are always sent as sensitive
+func IsSensitiveReference(value string) bool {
+	return strings.HasPrefix(value, vaultValuePrefix)
+}
+
+// GetSensitiveCommandKeys gets the keys of the variables from the command line that reference a secret store
+func GetSensitiveCommandKeys(values []string) map[string]bool {
+	sensitiveKeys := make(map[string]bool)
+	for _, e := range values {
+		pair := strings.SplitN(e, "=", 2)
+		if len(pair) == 2 && IsSensitiveReference(pair[1]) {
+			sensitiveKeys[pair[0]] = true
+		}
+	}
+	return sensitiveKeys
+}
+
+// FillSensitiveValues sets the values of the sensitive variables that have none, since the API never returns them.
+// The sensitive variables without a supplied value are left out and their keys are returned as missing
+func FillSensitiveValues(variables []NewVariable, values map[string]string) ([]NewVariable, []string) {
+	filled := make([]NewVariable, 0, len(variables))
+	missing := make([]string, 0)
+	for _, variable := range variables {
+		if variable.Sensitive && variable.Value == "" {
+			value, found := values[variable.Key]
+			if !found || value == "" {
+				missing = append(missing, variable.Key)
+				continue
+			}
+			variable.Value = value
+		}
+		filled = append(filled, variable)
+	}
+	return filled, missing
+}
+
+// LoadValues gets the values of the variables in the values files and the KEY=value pairs.
+// The pairs win over the files and their values can be references such as vault:path#field
+func LoadValues(valuesFiles []string, keyPairs []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, valuesFile := range valuesFiles {
+		fileVariables, err := LoadVariableFile(valuesFile, NewVariable{})
+		if err != nil {
+			return nil, err
+		}
+		for _, variable := range fileVariables {
+			values[variable.Key] = variable.Value
+		}
+	}
+	commandValues, err := ResolveCommandValues(keyPairs)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range commandValues {
+		values[key] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,142 @@
+package helper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setEnv sets an environment variable for the duration of a test
+func setEnv(t *testing.T, key string, value string) {
+	previous, found := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if found {
+			os.Setenv(key, previous)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestResolveValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "value.txt")
+	if err := ioutil.WriteFile(path, []byte("from file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	setEnv(t, "TFC_HELPER_TEST_VALUE", "from env")
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{name: "literal", value: "plain", want: "plain"},
+		{name: "empty", value: "", want: ""},
+		{name: "literal with prefix in the middle", value: "a@b env:c", want: "a@b env:c"},
+		{name: "file", value: "@" + path, want: "from file\n"},
+		{name: "missing file", value: "@" + path + ".missing", wantErr: "failed to read value from file"},
+		{name: "environment variable", value: "env:TFC_HELPER_TEST_VALUE", want: "from env"},
+		{name: "missing environment variable", value: "env:TFC_HELPER_TEST_MISSING", wantErr: "TFC_HELPER_TEST_MISSING is not set"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ResolveValue(test.value)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCommandValues(t *testing.T) {
+	setEnv(t, "TFC_HELPER_TEST_VALUE", "from env")
+	pairs := []string{"PLAIN=value", "FROM_ENV=env:TFC_HELPER_TEST_VALUE", "ONLY_KEY", "WITH_EQUALS=a=b", "FROM_STDIN=-"}
+
+	// The keys given to delete must not read the standard input or any other source
+	literal := GetCommandValues(pairs)
+	wantLiteral := map[string]string{
+		"PLAIN":       "value",
+		"FROM_ENV":    "env:TFC_HELPER_TEST_VALUE",
+		"ONLY_KEY":    "",
+		"WITH_EQUALS": "a=b",
+		"FROM_STDIN":  "-",
+	}
+	if !reflect.DeepEqual(literal, wantLiteral) {
+		t.Errorf("GetCommandValues got %v, want %v", literal, wantLiteral)
+	}
+
+	resolved, err := ResolveCommandValues(pairs[:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantResolved := map[string]string{"PLAIN": "value", "FROM_ENV": "from env", "ONLY_KEY": "", "WITH_EQUALS": "a=b"}
+	if !reflect.DeepEqual(resolved, wantResolved) {
+		t.Errorf("ResolveCommandValues got %v, want %v", resolved, wantResolved)
+	}
+
+	if _, err := ResolveCommandValues([]string{"MISSING=env:TFC_HELPER_TEST_MISSING"}); err == nil || !strings.Contains(err.Error(), "cannot get the value of MISSING") {
+		t.Errorf("got error %v, want an error naming MISSING", err)
+	}
+}
+
+func TestGetSensitiveCommandKeys(t *testing.T) {
+	got := GetSensitiveCommandKeys([]string{"A=vault:secret/data/app#a", "B=plain", "C", "D=env:NAME"})
+	if want := map[string]bool{"A": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFillSensitiveValues(t *testing.T) {
+	variables := []NewVariable{
+		{Key: "REGION", Value: "us-east-1"},
+		{Key: "DB_PASSWORD", Sensitive: true},
+		{Key: "API_TOKEN", Sensitive: true},
+		{Key: "EMPTY_SUPPLIED", Sensitive: true},
+		{Key: "KNOWN", Value: "kept", Sensitive: true},
+	}
+	values := map[string]string{"DB_PASSWORD": "hunter2", "EMPTY_SUPPLIED": "", "KNOWN": "ignored"}
+
+	filled, missing := FillSensitiveValues(variables, values)
+	wantFilled := []NewVariable{
+		{Key: "REGION", Value: "us-east-1"},
+		{Key: "DB_PASSWORD", Value: "hunter2", Sensitive: true},
+		{Key: "KNOWN", Value: "kept", Sensitive: true},
+	}
+	if !reflect.DeepEqual(filled, wantFilled) {
+		t.Errorf("got %+v, want %+v", filled, wantFilled)
+	}
+	// Blank sensitive values are never written, they are reported instead
+	if want := []string{"API_TOKEN", "EMPTY_SUPPLIED"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("got missing %v, want %v", missing, want)
+	}
+}
+
+func TestReadsStandardInput(t *testing.T) {
+	tests := map[string]bool{
+		"A=-":        true,
+		"A=--":       false,
+		"A=plain":    false,
+		"A":          false,
+		"A=env:NAME": false,
+	}
+	for pair, want := range tests {
+		if got := ReadsStandardInput([]string{"B=other", pair}); got != want {
+			t.Errorf("ReadsStandardInput(%q) = %v, want %v", pair, got, want)
+		}
+	}
+}
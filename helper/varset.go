@@ -0,0 +1,269 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// VariableSet is a set of variables shared by several workspaces, or by every workspace of
+// the organization when it is global. go-tfe does not support variable sets yet, so the API is called directly
+type VariableSet struct {
+	ID           string
+	Name         string
+	Description  string
+	Organization string
+	Global       bool
+	WorkspaceIDs []string
+}
+
+// ListVariableSets lists the variable sets of the organization
+func ListVariableSets(orgName string) ([]VariableSet, error) {
+	resources, err := apiList(fmt.Sprintf("organizations/%s/varsets", url.PathEscape(orgName)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the variable sets of organization %s: %s", orgName, err)
+	}
+
+	sets := make([]VariableSet, 0, len(resources))
+	for _, resource := range resources {
+		sets = append(sets, variableSetFromResource(orgName, resource))
+	}
+	return sets, nil
+}
+
+// FindVariableSet finds the variable set with the name in the organization
+func FindVariableSet(orgName string, name string) (VariableSet, error) {
+	sets, err := ListVariableSets(orgName)
+	if err != nil {
+		return VariableSet{}, err
+	}
+	for _, set := range sets {
+		if set.Name == name {
+			return set, nil
+		}
+	}
+	return VariableSet{}, fmt.Errorf("variable set %s not found in organization %s", name, orgName)
+}
+
+// CreateVariableSet creates an empty variable set in the organization
+func CreateVariableSet(orgName string, name string, description string, global bool) (VariableSet, error) {
+	if readOnly {
+		return VariableSet{}, ErrReadOnly
+	}
+
+	document, err := apiRequest("POST", fmt.Sprintf("organizations/%s/varsets", url.PathEscape(orgName)), nil, apiResource{
+		Type: "varsets",
+		Attributes: map[string]interface{}{
+			"name":        name,
+			"description": description,
+			"global":      global,
+		},
+	})
+	if err != nil {
+		return VariableSet{}, fmt.Errorf("failed to create the variable set %s: %s", name, err)
+	}
+
+	var resource apiResource
+	if document == nil || json.Unmarshal(document.Data, &resource) != nil {
+		return VariableSet{}, fmt.Errorf("failed to read the variable set %s after creating it", name)
+	}
+	set := variableSetFromResource(orgName, resource)
+	writeVariableSetAuditRecord(set, "", "create-set", nil, nil)
+	return set, nil
+}
+
+// SetVariableSetGlobal makes a variable set global, so every workspace of the organization uses it, or not
+func SetVariableSetGlobal(set VariableSet, global bool) error {
+	if readOnly {
+		return ErrReadOnly
+	}
+
+	_, err := apiRequest("PATCH", fmt.Sprintf("varsets/%s", set.ID), nil, apiResource{
+		ID:         set.ID,
+		Type:       "varsets",
+		Attributes: map[string]interface{}{"global": global},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the variable set %s: %s", set.Name, err)
+	}
+
+	action := "unset-global"
+	if global {
+		action = "set-global"
+	}
+	writeVariableSetAuditRecord(set, "", action, nil, nil)
+	return nil
+}
+
+// AttachVariableSet attaches the variable set to a workspace
+func AttachVariableSet(set VariableSet, workspace *tfe.Workspace) error {
+	return changeVariableSetWorkspace(set, workspace, "POST", "attach")
+}
+
+// DetachVariableSet detaches the variable set from a workspace
+func DetachVariableSet(set VariableSet, workspace *tfe.Workspace) error {
+	return changeVariableSetWorkspace(set, workspace, "DELETE", "detach")
+}
+
+// changeVariableSetWorkspace adds or removes a workspace from the workspaces of a variable set
+func changeVariableSetWorkspace(set VariableSet, workspace *tfe.Workspace, method string, action string) error {
+	if readOnly {
+		return ErrReadOnly
+	}
+
+	_, err := apiRequest(method, fmt.Sprintf("varsets/%s/relationships/workspaces", set.ID), nil, []apiResource{
+		{ID: workspace.ID, Type: "workspaces"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to %s the variable set %s and workspace %s: %s", action, set.Name, workspace.Name, err)
+	}
+	writeVariableSetAuditRecord(set, workspace.Name, action, nil, nil)
+	return nil
+}
+
+// ListVariableSetVariables lists the variables of a variable set
+func ListVariableSetVariables(set VariableSet) ([]*tfe.Variable, error) {
+	resources, err := apiList(fmt.Sprintf("varsets/%s/relationships/vars", set.ID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the variables of the variable set %s: %s", set.Name, err)
+	}
+
+	variables := make([]*tfe.Variable, 0, len(resources))
+	for _, resource := range resources {
+		variables = append(variables, &tfe.Variable{
+			ID:          resource.ID,
+			Key:         stringAttribute(resource, "key"),
+			Value:       stringAttribute(resource, "value"),
+			Description: stringAttribute(resource, "description"),
+			Category:    tfe.CategoryType(stringAttribute(resource, "category")),
+			HCL:         boolAttribute(resource, "hcl"),
+			Sensitive:   boolAttribute(resource, "sensitive"),
+		})
+	}
+	return variables, nil
+}
+
+// CreateVariableSetVariable creates a variable in a variable set
+func CreateVariableSetVariable(set VariableSet, newVariable NewVariable) error {
+	if readOnly {
+		return ErrReadOnly
+	}
+	if err := checkSensitiveValue(newVariable); err != nil {
+		return err
+	}
+
+	_, err := apiRequest("POST", fmt.Sprintf("varsets/%s/relationships/vars", set.ID), nil, apiResource{
+		Type:       "vars",
+		Attributes: variableSetAttributes(newVariable),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s in the variable set %s: %s", newVariable.Key, set.Name, err)
+	}
+	writeVariableSetAuditRecord(set, "", "create", nil, &newVariable)
+	return nil
+}
+
+// UpdateVariableSetVariable updates a variable of a variable set given the variable id
+func UpdateVariableSetVariable(set VariableSet, oldVariable *tfe.Variable, newVariable NewVariable) error {
+	if readOnly {
+		return ErrReadOnly
+	}
+
+	_, err := apiRequest("PATCH", fmt.Sprintf("varsets/%s/relationships/vars/%s", set.ID, newVariable.ID), nil, apiResource{
+		ID:         newVariable.ID,
+		Type:       "vars",
+		Attributes: variableSetAttributes(newVariable),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update %s in the variable set %s: %s", newVariable.Key, set.Name, err)
+	}
+	writeVariableSetAuditRecord(set, "", "update", oldVariable, &newVariable)
+	return nil
+}
+
+// DeleteVariableSetVariable deletes a variable of a variable set
+func DeleteVariableSetVariable(set VariableSet, variable *tfe.Variable) error {
+	if readOnly {
+		return ErrReadOnly
+	}
+
+	if _, err := apiRequest("DELETE", fmt.Sprintf("varsets/%s/relationships/vars/%s", set.ID, variable.ID), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete %s from the variable set %s: %s", variable.Key, set.Name, err)
+	}
+	writeVariableSetAuditRecord(set, "", "delete", variable, nil)
+	return nil
+}
+
+// ApplyVariableSetChange applies a single change to a variable set. Skipped changes do nothing
+func ApplyVariableSetChange(set VariableSet, change Change) error {
+	switch change.Action {
+	case ActionCreate:
+		return CreateVariableSetVariable(set, change.Variable)
+	case ActionUpdate:
+		return UpdateVariableSetVariable(set, change.Existing, change.Variable)
+	case ActionRecreate:
+		// Check before deleting, otherwise the variable would be lost
+		if err := CheckRecreateValue(change.Existing, change.Variable); err != nil {
+			return err
+		}
+		if err := DeleteVariableSetVariable(set, change.Existing); err != nil {
+			return err
+		}
+		return CreateVariableSetVariable(set, change.Variable)
+	case ActionDelete:
+		return DeleteVariableSetVariable(set, change.Existing)
+	}
+	return nil
+}
+
+// variableSetAttributes gets the attributes of a variable sent to the API.
+// The value is left out when the stored value of a sensitive variable is kept
+func variableSetAttributes(newVariable NewVariable) map[string]interface{} {
+	attributes := map[string]interface{}{
+		"key":         newVariable.Key,
+		"description": newVariable.Description,
+		"category":    string(newVariable.Category),
+		"hcl":         newVariable.HCL,
+		"sensitive":   newVariable.Sensitive,
+	}
+	if !keepsSensitiveValue(newVariable) {
+		attributes["value"] = newVariable.Value
+	}
+	return attributes
+}
+
+// variableSetFromResource reads a variable set from its JSON:API resource
+func variableSetFromResource(orgName string, resource apiResource) VariableSet {
+	set := VariableSet{
+		ID:           resource.ID,
+		Name:         stringAttribute(resource, "name"),
+		Description:  stringAttribute(resource, "description"),
+		Organization: orgName,
+		Global:       boolAttribute(resource, "global"),
+		WorkspaceIDs: make([]string, 0),
+	}
+
+	if relationship, found := resource.Relationships["workspaces"]; found {
+		var workspaces []apiResource
+		if json.Unmarshal(relationship.Data, &workspaces) == nil {
+			for _, workspace := range workspaces {
+				set.WorkspaceIDs = append(set.WorkspaceIDs, workspace.ID)
+			}
+		}
+	}
+	return set
+}
+
+// stringAttribute gets a string attribute of a resource, or an empty string when it is not set
+func stringAttribute(resource apiResource, name string) string {
+	value, _ := resource.Attributes[name].(string)
+	return value
+}
+
+// boolAttribute gets a boolean attribute of a resource, or false when it is not set
+func boolAttribute(resource apiResource, name string) bool {
+	value, _ := resource.Attributes[name].(bool)
+	return value
+}
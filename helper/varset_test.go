@@ -0,0 +1,167 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// writeTestVariableSets answers with a JSON:API document of a list of variable sets.
+// Each set is written as "id:name:global:workspace-id,workspace-id"
+func writeTestVariableSets(w http.ResponseWriter, sets ...string) {
+	items := make([]string, 0, len(sets))
+	for _, set := range sets {
+		fields := strings.Split(set, ":")
+		workspaces := make([]string, 0)
+		if fields[3] != "" {
+			for _, id := range strings.Split(fields[3], ",") {
+				workspaces = append(workspaces, fmt.Sprintf(`{"id":%q,"type":"workspaces"}`, id))
+			}
+		}
+		items = append(items, fmt.Sprintf(`{"id":%q,"type":"varsets","attributes":{"name":%q,"global":%s},"relationships":{"workspaces":{"data":[%s]}}}`,
+			fields[0], fields[1], fields[2], strings.Join(workspaces, ",")))
+	}
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(items, ","))
+}
+
+func TestListVariableSets(t *testing.T) {
+	requests := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		writeTestVariableSets(w, "varset-1:shared:false:ws-1,ws-2", "varset-2:defaults:true:")
+	})
+
+	sets, err := ListVariableSets("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []VariableSet{
+		{ID: "varset-1", Name: "shared", Organization: "acme", WorkspaceIDs: []string{"ws-1", "ws-2"}},
+		{ID: "varset-2", Name: "defaults", Organization: "acme", Global: true, WorkspaceIDs: []string{}},
+	}
+	if !reflect.DeepEqual(sets, want) {
+		t.Errorf("got %+v, want %+v", sets, want)
+	}
+	if got := requests(); !reflect.DeepEqual(got, []string{"GET organizations/acme/varsets"}) {
+		t.Errorf("got requests %v", got)
+	}
+
+	if _, err := FindVariableSet("acme", "missing"); err == nil {
+		t.Error("a missing variable set was found")
+	}
+}
+
+func TestListVariableSetVariables(t *testing.T) {
+	newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		writeTestVariables(w,
+			&tfe.Variable{ID: "var-1", Key: "region", Value: "us-east-1", Description: "AWS region", Category: tfe.CategoryTerraform},
+			&tfe.Variable{ID: "var-2", Key: "DB_PASSWORD", Category: tfe.CategoryEnv, Sensitive: true},
+			&tfe.Variable{ID: "var-3", Key: "tags", Value: `{ team = "infra" }`, Category: tfe.CategoryTerraform, HCL: true},
+		)
+	})
+
+	variables, err := ListVariableSetVariables(VariableSet{ID: "varset-1", Name: "shared"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*tfe.Variable{
+		{ID: "var-1", Key: "region", Value: "us-east-1", Description: "AWS region", Category: tfe.CategoryTerraform},
+		{ID: "var-2", Key: "DB_PASSWORD", Category: tfe.CategoryEnv, Sensitive: true},
+		{ID: "var-3", Key: "tags", Value: `{ team = "infra" }`, Category: tfe.CategoryTerraform, HCL: true},
+	}
+	if !reflect.DeepEqual(variables, want) {
+		t.Errorf("got %+v, want %+v", variables, want)
+	}
+}
+
+func TestApplyVariableSetChange(t *testing.T) {
+	set := VariableSet{ID: "varset-1", Name: "shared"}
+	existing := &tfe.Variable{ID: "var-1", Key: "DB_PASSWORD", Category: tfe.CategoryEnv, Sensitive: true}
+
+	tests := []struct {
+		name           string
+		change         Change
+		wantErr        bool
+		want           []string
+		wantAttributes map[string]interface{}
+	}{
+		{
+			name:   "create",
+			change: Change{Action: ActionCreate, Variable: NewVariable{Key: "region", Value: "us-east-1", Category: tfe.CategoryTerraform}},
+			want:   []string{"POST varsets/varset-1/relationships/vars"},
+			wantAttributes: map[string]interface{}{
+				"key": "region", "value": "us-east-1", "description": "", "category": "terraform", "hcl": false, "sensitive": false,
+			},
+		},
+		{
+			name:   "update keeps the sensitive value",
+			change: Change{Action: ActionUpdate, Existing: existing, Variable: NewVariable{ID: "var-1", Key: "DB_PASSWORD", Category: tfe.CategoryEnv, Sensitive: true}},
+			want:   []string{"PATCH varsets/varset-1/relationships/vars/var-1"},
+			wantAttributes: map[string]interface{}{
+				"key": "DB_PASSWORD", "description": "", "category": "env", "hcl": false, "sensitive": true,
+			},
+		},
+		{
+			name:   "recreate",
+			change: Change{Action: ActionRecreate, Existing: existing, Variable: NewVariable{Key: "DB_PASSWORD", Value: "hunter2", Category: tfe.CategoryTerraform, Sensitive: true}},
+			want:   []string{"DELETE varsets/varset-1/relationships/vars/var-1", "POST varsets/varset-1/relationships/vars"},
+			wantAttributes: map[string]interface{}{
+				"key": "DB_PASSWORD", "value": "hunter2", "description": "", "category": "terraform", "hcl": false, "sensitive": true,
+			},
+		},
+		{
+			name:    "recreate without the sensitive value",
+			change:  Change{Action: ActionRecreate, Existing: existing, Variable: NewVariable{Key: "DB_PASSWORD", Category: tfe.CategoryTerraform, Sensitive: true}},
+			wantErr: true,
+			want:    []string{},
+		},
+		{
+			name:   "delete",
+			change: Change{Action: ActionDelete, Existing: existing},
+			want:   []string{"DELETE varsets/varset-1/relationships/vars/var-1"},
+		},
+		{
+			name:   "skip",
+			change: Change{Action: ActionSkip, Existing: existing},
+			want:   []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var attributes map[string]interface{}
+			requests := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "DELETE" {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				var document struct {
+					Data apiResource `json:"data"`
+				}
+				content, _ := ioutil.ReadAll(r.Body)
+				if err := json.Unmarshal(content, &document); err != nil {
+					t.Error(err)
+				}
+				attributes = document.Data.Attributes
+				writeTestVariable(w, http.StatusOK, "var-2", test.change.Variable.Key, test.change.Variable.Sensitive)
+			})
+
+			err := ApplyVariableSetChange(set, test.change)
+			if test.wantErr != (err != nil) {
+				t.Fatalf("got error %v, want error %t", err, test.wantErr)
+			}
+			// A variable is never deleted when it cannot be created again
+			if got := requests(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got requests %v, want %v", got, test.want)
+			}
+			if !reflect.DeepEqual(attributes, test.wantAttributes) {
+				t.Errorf("got attributes %v, want %v", attributes, test.wantAttributes)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package helper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// vaultValuePrefix is the prefix of the values read from a Vault KV secret
+const vaultValuePrefix = "vault:"
+
+var vaultSecrets = make(map[string]map[string]interface{})
+var vaultMutex sync.Mutex
+
+// vaultResponse is the part of the Vault read secret response the tool uses
+type vaultResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []string               `json:"errors"`
+}
+
+// readVaultValue reads a field of a Vault KV secret referenced as path#field, such as secret/data/app#password.
+// Both KV version 1 and version 2 secrets are supported. Vault is configured with VAULT_ADDR, VAULT_TOKEN,
+// VAULT_NAMESPACE, VAULT_CACERT and VAULT_SKIP_VERIFY like the Vault CLI
+func readVaultValue(reference string) (string, error) {
+	parts := strings.SplitN(reference, "#", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid Vault reference %q, it should be vault:path#field", vaultValuePrefix+reference)
+	}
+	path, field := strings.Trim(parts[0], "/"), parts[1]
+
+	secret, err := readVaultSecret(path)
+	if err != nil {
+		return "", err
+	}
+
+	value, found := secret[field]
+	if !found {
+		return "", fmt.Errorf("field %s not found in Vault secret %s", field, path)
+	}
+	if stringValue, ok := value.(string); ok {
+		return stringValue, nil
+	}
+	// Keep numbers, lists and maps in their JSON form
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonValue), nil
+}
+
+// readVaultSecret reads a Vault secret once and keeps it for the other fields of the same secret
+func readVaultSecret(path string) (map[string]interface{}, error) {
+	vaultMutex.Lock()
+	defer vaultMutex.Unlock()
+	if secret, found := vaultSecrets[path]; found {
+		return secret, nil
+	}
+
+	address := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	token := os.Getenv("VAULT_TOKEN")
+	if address == "" || token == "" {
+		return nil, errors.New("VAULT_ADDR and VAULT_TOKEN have to be set to read values from Vault")
+	}
+
+	skipVerify, _ := strconv.ParseBool(os.Getenv("VAULT_SKIP_VERIFY"))
+	httpClient, err := newHTTPClient(TransportConfig{
+		CACert:             os.Getenv("VAULT_CACERT"),
+		InsecureSkipVerify: skipVerify,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/%s", address, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		request.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Vault secret %s: %s", path, err)
+	}
+	defer response.Body.Close()
+
+	var body vaultResponse
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil && response.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("invalid response for Vault secret %s: %s", path, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		if len(body.Errors) > 0 {
+			return nil, fmt.Errorf("failed to read Vault secret %s: %s", path, strings.Join(body.Errors, ", "))
+		}
+		return nil, fmt.Errorf("failed to read Vault secret %s: %s", path, response.Status)
+	}
+
+	secret := body.Data
+	// KV version 2 secrets have the fields under data and the version under metadata
+	if nested, ok := secret["data"].(map[string]interface{}); ok {
+		if _, versioned := secret["metadata"]; versioned {
+			secret = nested
+		}
+	}
+
+	vaultSecrets[path] = secret
+	return secret, nil
+}
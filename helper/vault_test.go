@@ -0,0 +1,105 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveVaultValue(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Vault-Token") != "test-token" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/app":
+			w.Write([]byte(`{"data":{"data":{"password":"s3cr3t","port":5432,"hosts":["a","b"]},"metadata":{"version":3}}}`))
+		case "/v1/kv/app":
+			w.Write([]byte(`{"data":{"password":"v1-secret"}}`))
+		case "/v1/secret/data/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	setEnv(t, "VAULT_ADDR", server.URL+"/")
+	setEnv(t, "VAULT_TOKEN", "test-token")
+	setEnv(t, "VAULT_NAMESPACE", "team")
+	vaultSecrets = make(map[string]map[string]interface{})
+
+	tests := []struct {
+		name      string
+		reference string
+		want      string
+		wantErr   string
+	}{
+		{name: "kv v2 field", reference: "vault:secret/data/app#password", want: "s3cr3t"},
+		{name: "kv v2 number", reference: "vault:secret/data/app#port", want: "5432"},
+		{name: "kv v2 list", reference: "vault:/secret/data/app/#hosts", want: `["a","b"]`},
+		{name: "kv v1 field", reference: "vault:kv/app#password", want: "v1-secret"},
+		{name: "missing field", reference: "vault:secret/data/app#username", wantErr: "field username not found in Vault secret secret/data/app"},
+		{name: "missing secret", reference: "vault:secret/data/other#password", wantErr: "404 Not Found"},
+		{name: "server error", reference: "vault:secret/data/broken#password", wantErr: "500 Internal Server Error"},
+		{name: "no field", reference: "vault:secret/data/app", wantErr: "it should be vault:path#field"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !IsSensitiveReference(test.reference) {
+				t.Errorf("%s is not a sensitive reference", test.reference)
+			}
+			got, err := ResolveValue(test.reference)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	// The fields of a secret are read with a single request
+	if requests != 4 {
+		t.Errorf("got %d requests to Vault, want 4", requests)
+	}
+}
+
+func TestResolveVaultValueWithoutToken(t *testing.T) {
+	setEnv(t, "VAULT_ADDR", "http://127.0.0.1:1")
+	setEnv(t, "VAULT_TOKEN", "")
+	vaultSecrets = make(map[string]map[string]interface{})
+
+	if _, err := ResolveValue("vault:secret/data/app#password"); err == nil || !strings.Contains(err.Error(), "VAULT_TOKEN") {
+		t.Fatalf("got error %v, want an error about VAULT_TOKEN", err)
+	}
+}
+
+func TestResolveVaultValuePermissionDenied(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":["permission denied"]}`))
+	}))
+	defer server.Close()
+
+	setEnv(t, "VAULT_ADDR", server.URL)
+	setEnv(t, "VAULT_TOKEN", "wrong")
+	vaultSecrets = make(map[string]map[string]interface{})
+
+	if _, err := ResolveValue("vault:secret/data/app#password"); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("got error %v, want permission denied", err)
+	}
+}